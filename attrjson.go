@@ -0,0 +1,133 @@
+package dynastorev2
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// attributeValueJSON is the DynamoDB JSON representation of an attribute value, this is used to serialise
+// payloads to bytes without losing any of the type information captured when they were marshalled
+type attributeValueJSON struct {
+	S    *string                         `json:"S,omitempty"`
+	N    *string                         `json:"N,omitempty"`
+	B    *[]byte                         `json:"B,omitempty"`
+	SS   []string                        `json:"SS,omitempty"`
+	NS   []string                        `json:"NS,omitempty"`
+	BS   [][]byte                        `json:"BS,omitempty"`
+	M    *map[string]*attributeValueJSON `json:"M,omitempty"`
+	L    *[]*attributeValueJSON          `json:"L,omitempty"`
+	NULL *bool                           `json:"NULL,omitempty"`
+	BOOL *bool                           `json:"BOOL,omitempty"`
+}
+
+// encodeAttributeValue serialises the attribute value to DynamoDB JSON
+func encodeAttributeValue(av types.AttributeValue) ([]byte, error) {
+	v, err := toAttributeValueJSON(av)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
+
+// decodeAttributeValue deserialises an attribute value from DynamoDB JSON
+func decodeAttributeValue(data []byte) (types.AttributeValue, error) {
+	v := new(attributeValueJSON)
+
+	err := json.Unmarshal(data, v)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to unmarshal attribute value: %w", err)
+	}
+
+	return fromAttributeValueJSON(v)
+}
+
+func toAttributeValueJSON(av types.AttributeValue) (*attributeValueJSON, error) {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return &attributeValueJSON{S: &v.Value}, nil
+	case *types.AttributeValueMemberN:
+		return &attributeValueJSON{N: &v.Value}, nil
+	case *types.AttributeValueMemberB:
+		return &attributeValueJSON{B: &v.Value}, nil
+	case *types.AttributeValueMemberSS:
+		return &attributeValueJSON{SS: v.Value}, nil
+	case *types.AttributeValueMemberNS:
+		return &attributeValueJSON{NS: v.Value}, nil
+	case *types.AttributeValueMemberBS:
+		return &attributeValueJSON{BS: v.Value}, nil
+	case *types.AttributeValueMemberNULL:
+		return &attributeValueJSON{NULL: &v.Value}, nil
+	case *types.AttributeValueMemberBOOL:
+		return &attributeValueJSON{BOOL: &v.Value}, nil
+	case *types.AttributeValueMemberM:
+		m := make(map[string]*attributeValueJSON, len(v.Value))
+		for k, mv := range v.Value {
+			jv, err := toAttributeValueJSON(mv)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = jv
+		}
+		return &attributeValueJSON{M: &m}, nil
+	case *types.AttributeValueMemberL:
+		l := make([]*attributeValueJSON, 0, len(v.Value))
+		for _, lv := range v.Value {
+			jv, err := toAttributeValueJSON(lv)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, jv)
+		}
+		return &attributeValueJSON{L: &l}, nil
+	default:
+		return nil, fmt.Errorf("dynastorev2: unsupported attribute value type %T", av)
+	}
+}
+
+func fromAttributeValueJSON(v *attributeValueJSON) (types.AttributeValue, error) {
+	switch {
+	case v == nil:
+		return nil, fmt.Errorf("dynastorev2: attribute value is missing")
+	case v.S != nil:
+		return &types.AttributeValueMemberS{Value: *v.S}, nil
+	case v.N != nil:
+		return &types.AttributeValueMemberN{Value: *v.N}, nil
+	case v.B != nil:
+		return &types.AttributeValueMemberB{Value: *v.B}, nil
+	case v.SS != nil:
+		return &types.AttributeValueMemberSS{Value: v.SS}, nil
+	case v.NS != nil:
+		return &types.AttributeValueMemberNS{Value: v.NS}, nil
+	case v.BS != nil:
+		return &types.AttributeValueMemberBS{Value: v.BS}, nil
+	case v.NULL != nil:
+		return &types.AttributeValueMemberNULL{Value: *v.NULL}, nil
+	case v.BOOL != nil:
+		return &types.AttributeValueMemberBOOL{Value: *v.BOOL}, nil
+	case v.M != nil:
+		m := make(map[string]types.AttributeValue, len(*v.M))
+		for k, mv := range *v.M {
+			av, err := fromAttributeValueJSON(mv)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = av
+		}
+		return &types.AttributeValueMemberM{Value: m}, nil
+	case v.L != nil:
+		l := make([]types.AttributeValue, 0, len(*v.L))
+		for _, lv := range *v.L {
+			av, err := fromAttributeValueJSON(lv)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, av)
+		}
+		return &types.AttributeValueMemberL{Value: l}, nil
+	default:
+		return nil, fmt.Errorf("dynastorev2: attribute value has no type set")
+	}
+}
@@ -0,0 +1,177 @@
+package dynastorev2
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultBlobThreshold this is the default size in bytes of an encoded payload above which it is offloaded to the blob store,
+// this leaves some headroom below the 400KB item size limit for the keys and extra fields
+const DefaultBlobThreshold = 350 * 1024
+
+// BlobStore stores payloads which are too large to be written to DynamoDB, for example in Amazon S3.
+//
+// Each offloaded payload is written to a new key, so implementations don't need to handle concurrent writers.
+type BlobStore interface {
+	// PutBlob stores the data under the provided key
+	PutBlob(ctx context.Context, key string, data []byte) error
+	// GetBlob retrieves the data stored under the provided key
+	GetBlob(ctx context.Context, key string) ([]byte, error)
+	// DeleteBlob removes the data stored under the provided key, this should not fail if the key doesn't exist
+	DeleteBlob(ctx context.Context, key string) error
+}
+
+// FileBlobStore is a BlobStore which stores blobs as files in a directory, this is intended for testing and local development.
+type FileBlobStore struct {
+	dir string
+}
+
+// NewFileBlobStore creates a blob store which stores blobs as files under the provided directory
+func NewFileBlobStore(dir string) *FileBlobStore {
+	return &FileBlobStore{dir: dir}
+}
+
+// PutBlob writes the data to a file named using the key
+func (fb *FileBlobStore) PutBlob(ctx context.Context, key string, data []byte) error {
+	path, err := fb.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return fmt.Errorf("dynastorev2: failed to create blob directory: %w", err)
+	}
+
+	// write to a temporary file and rename it to ensure readers never see a partially written blob
+	tmp := path + ".tmp"
+
+	err = os.WriteFile(tmp, data, 0o600)
+	if err != nil {
+		return fmt.Errorf("dynastorev2: failed to write blob: %w", err)
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// GetBlob reads the data from the file named using the key
+func (fb *FileBlobStore) GetBlob(ctx context.Context, key string) ([]byte, error) {
+	path, err := fb.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to read blob: %w", err)
+	}
+
+	return data, nil
+}
+
+// DeleteBlob removes the file named using the key
+func (fb *FileBlobStore) DeleteBlob(ctx context.Context, key string) error {
+	path, err := fb.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("dynastorev2: failed to delete blob: %w", err)
+	}
+
+	return nil
+}
+
+func (fb *FileBlobStore) path(key string) (string, error) {
+	name := filepath.FromSlash(key)
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("dynastorev2: invalid blob key %q", key)
+	}
+
+	return filepath.Join(fb.dir, name), nil
+}
+
+// newBlobKey generates a unique blob key for the item, prefixed with a hash of the keys to group blobs by item
+func newBlobKey(key map[string]types.AttributeValue, pkName, skName string) (string, error) {
+	aad, err := keyAAD(key[pkName], key[skName])
+	if err != nil {
+		return "", err
+	}
+
+	suffix := make([]byte, 16)
+
+	_, err = rand.Read(suffix)
+	if err != nil {
+		return "", fmt.Errorf("dynastorev2: failed to generate blob key: %w", err)
+	}
+
+	itemHash := sha256.Sum256(aad)
+
+	return fmt.Sprintf("%s/%s", hex.EncodeToString(itemHash[:]), hex.EncodeToString(suffix)), nil
+}
+
+func blobChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// attributeSize calculates the size of the attribute value using the rules DynamoDB applies to item sizes,
+// see https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/CapacityUnitCalculations.html
+func attributeSize(av types.AttributeValue) int {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return len(v.Value)
+	case *types.AttributeValueMemberN:
+		return numberSize(v.Value)
+	case *types.AttributeValueMemberB:
+		return len(v.Value)
+	case *types.AttributeValueMemberSS:
+		size := 0
+		for _, s := range v.Value {
+			size += len(s)
+		}
+		return size
+	case *types.AttributeValueMemberNS:
+		size := 0
+		for _, n := range v.Value {
+			size += numberSize(n)
+		}
+		return size
+	case *types.AttributeValueMemberBS:
+		size := 0
+		for _, b := range v.Value {
+			size += len(b)
+		}
+		return size
+	case *types.AttributeValueMemberM:
+		size := 3
+		for k, mv := range v.Value {
+			size += len(k) + attributeSize(mv) + 1
+		}
+		return size
+	case *types.AttributeValueMemberL:
+		size := 3
+		for _, lv := range v.Value {
+			size += attributeSize(lv) + 1
+		}
+		return size
+	default:
+		// NULL and BOOL attributes are a single byte
+		return 1
+	}
+}
+
+func numberSize(n string) int {
+	return (len(n)+1)/2 + 1
+}
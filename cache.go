@@ -0,0 +1,273 @@
+package dynastorev2
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Cache stores encoded items read from or written to the table so they can be returned by Get and GetRecord without a
+// call to DynamoDB, implementations may be in process like LRUCache, or an external cache shared by many processes.
+type Cache interface {
+	// Get returns the entry for the key, the second return value is false if the entry isn't in the cache
+	Get(ctx context.Context, key string) (CacheEntry, bool, error)
+	// Set stores the entry for the key, replacing any existing entry
+	Set(ctx context.Context, key string, entry CacheEntry) error
+	// Delete removes the entry for the key
+	Delete(ctx context.Context, key string) error
+}
+
+// CacheEntry is an item encoded for storage in a cache, along with the decoded record for in process caches
+type CacheEntry struct {
+	// Version of the record, caches can use this to avoid replacing an entry with an older version
+	Version int64
+	// Expires is the time the record expires, the entry must not be returned after this time. Zero if the record doesn't expire.
+	Expires time.Time
+	// Data is the item encoded as DynamoDB JSON, this is empty for entries which record that the item was deleted or
+	// modified without the new item being returned. These entries are never served, but as they hold the latest
+	// version they prevent an older version of the item read concurrently from being cached.
+	Data []byte
+	// Record is the decoded record if it is known when the entry is set, in process caches such as LRUCache hold this
+	// so a hit doesn't decrypt the payload or read it from the blob store. External caches should store Data only.
+	Record any
+}
+
+// LRUCache is an in process Cache which holds a fixed number of entries, evicting the least recently used entry when
+// it is full.
+//
+// An entry is never replaced by an entry with a lower version, this prevents a slow read from overwriting the result
+// of a newer write.
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries *list.List
+	keys    map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	entry   CacheEntry
+	expires time.Time
+}
+
+// NewLRUCache creates a cache which holds up to size entries, each for at most ttl which bounds how long changes made
+// by other processes take to be seen. A ttl of zero holds entries until they are evicted or the record expires.
+func NewLRUCache(size int, ttl time.Duration) *LRUCache {
+	return &LRUCache{
+		size:    size,
+		ttl:     ttl,
+		entries: list.New(),
+		keys:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the entry for the key if it is present and hasn't expired
+func (c *LRUCache) Get(ctx context.Context, key string) (CacheEntry, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.keys[key]
+	if !ok {
+		return CacheEntry{}, false, nil
+	}
+
+	ent := elem.Value.(*lruEntry)
+
+	if !ent.expires.IsZero() && !time.Now().Before(ent.expires) {
+		c.remove(elem)
+		return CacheEntry{}, false, nil
+	}
+
+	c.entries.MoveToFront(elem)
+
+	return ent.entry, true, nil
+}
+
+// Set stores the entry for the key unless the cache holds a newer version of the record
+func (c *LRUCache) Set(ctx context.Context, key string, entry CacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := entry.Expires
+	if c.ttl > 0 {
+		if maxExpires := time.Now().Add(c.ttl); expires.IsZero() || maxExpires.Before(expires) {
+			expires = maxExpires
+		}
+	}
+
+	if elem, ok := c.keys[key]; ok {
+		ent := elem.Value.(*lruEntry)
+
+		if ent.entry.Version > entry.Version {
+			return nil
+		}
+
+		ent.entry, ent.expires = entry, expires
+		c.entries.MoveToFront(elem)
+
+		return nil
+	}
+
+	c.keys[key] = c.entries.PushFront(&lruEntry{key: key, entry: entry, expires: expires})
+
+	for c.size > 0 && c.entries.Len() > c.size {
+		c.remove(c.entries.Back())
+	}
+
+	return nil
+}
+
+// Delete removes the entry for the key
+func (c *LRUCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.keys[key]; ok {
+		c.remove(elem)
+	}
+
+	return nil
+}
+
+// Len returns the number of entries in the cache, including expired entries which haven't been removed yet
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries.Len()
+}
+
+func (c *LRUCache) remove(elem *list.Element) {
+	c.entries.Remove(elem)
+	delete(c.keys, elem.Value.(*lruEntry).key)
+}
+
+// cacheKey identifies the record in the cache using the table name and keys
+func (t *Store[P, S, V]) cacheKey(partitionKey P, sortKey S) string {
+	return fmt.Sprintf("%q/%q/%q", t.tableName, fmt.Sprint(partitionKey), fmt.Sprint(sortKey))
+}
+
+// getCachedRecord returns the cached record if it is present and hasn't expired, the item is only decoded if the cache
+// doesn't hold the decoded record. Errors from the cache are treated as a miss so the record is read from the table.
+func (t *Store[P, S, V]) getCachedRecord(ctx context.Context, partitionKey P, sortKey S) (Record[P, S, V], bool) {
+	var record Record[P, S, V]
+
+	entry, ok, err := t.storeOptions.cache.Get(ctx, t.cacheKey(partitionKey, sortKey))
+	if err != nil || !ok || len(entry.Data) == 0 {
+		return record, false
+	}
+
+	if !entry.Expires.IsZero() && !time.Now().Before(entry.Expires) {
+		return record, false
+	}
+
+	// the fields are copied so callers can't modify the cached record, the value is shared
+	if cached, ok := entry.Record.(Record[P, S, V]); ok {
+		cached.Fields = maps.Clone(cached.Fields)
+		return cached, true
+	}
+
+	av, err := decodeAttributeValue(entry.Data)
+	if err != nil {
+		return record, false
+	}
+
+	item, ok := av.(*types.AttributeValueMemberM)
+	if !ok {
+		return record, false
+	}
+
+	record, _, err = t.decodeRecord(ctx, item.Value)
+	if err != nil {
+		return record, false
+	}
+
+	return record, true
+}
+
+// setCachedItem stores the item in the cache along with the decoded record if the value is provided, this is best
+// effort as a failure results in the record being read from the table. Items read from the table don't replace a newer
+// version in the cache, while items written by the store replace any existing entry as the versions of records which
+// are deleted then created again start from one.
+func (t *Store[P, S, V]) setCachedItem(ctx context.Context, partitionKey P, sortKey S, item map[string]types.AttributeValue, value *V, written bool) {
+	if t.storeOptions.cache == nil {
+		return
+	}
+
+	record, err := t.decodeRecordMetadata(item)
+	if err != nil {
+		t.invalidateCachedRecord(ctx, partitionKey, sortKey)
+		return
+	}
+
+	entry := CacheEntry{
+		Version: record.Version,
+	}
+
+	if record.Expires != nil {
+		entry.Expires = *record.Expires
+	}
+
+	// soft deleted items are recorded without data so they aren't served
+	if !t.isDeleted(item) {
+		entry.Data, err = encodeAttributeValue(&types.AttributeValueMemberM{Value: item})
+		if err != nil {
+			t.invalidateCachedRecord(ctx, partitionKey, sortKey)
+			return
+		}
+
+		if value != nil {
+			record.Value = *value
+			entry.Record = record
+		}
+	}
+
+	t.setCacheEntry(ctx, partitionKey, sortKey, entry, written)
+}
+
+// supersedeCachedRecord replaces the cached item with an entry without data at the provided version, this is used
+// after a delete or a write which doesn't return the new item
+func (t *Store[P, S, V]) supersedeCachedRecord(ctx context.Context, partitionKey P, sortKey S, version int64) {
+	if t.storeOptions.cache == nil {
+		return
+	}
+
+	t.setCacheEntry(ctx, partitionKey, sortKey, CacheEntry{Version: version}, true)
+}
+
+func (t *Store[P, S, V]) setCacheEntry(ctx context.Context, partitionKey P, sortKey S, entry CacheEntry, replace bool) {
+	key := t.cacheKey(partitionKey, sortKey)
+
+	if replace {
+		_ = t.storeOptions.cache.Delete(ctx, key)
+	}
+
+	_ = t.storeOptions.cache.Set(ctx, key, entry)
+}
+
+// invalidateCachedRecord removes the record from the cache after it has been modified
+func (t *Store[P, S, V]) invalidateCachedRecord(ctx context.Context, partitionKey P, sortKey S) {
+	if t.storeOptions.cache == nil {
+		return
+	}
+
+	_ = t.storeOptions.cache.Delete(ctx, t.cacheKey(partitionKey, sortKey))
+}
+
+// itemVersion returns the version of the item, or zero if it doesn't have one
+func (t *Store[P, S, V]) itemVersion(item map[string]types.AttributeValue) int64 {
+	var version int64
+
+	if attr, ok := item[t.fields.versionName]; ok {
+		_ = attributevalue.Unmarshal(attr, &version)
+	}
+
+	return version
+}
@@ -0,0 +1,212 @@
+package dynastorev2
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type capacityAccountCtxKeyType string
+
+const capacityAccountCtxKey capacityAccountCtxKeyType = "capacityAccount"
+
+// Capacity holds the read and write capacity units consumed
+type Capacity struct {
+	ReadUnits  float64
+	WriteUnits float64
+}
+
+// TableCapacity holds the capacity units consumed by a table, along with the portion consumed by each index
+type TableCapacity struct {
+	Capacity
+	Indexes map[string]Capacity
+}
+
+// CapacityAccount accumulates the capacity consumed by every call made to DynamoDB by store operations using a context
+// it is attached to, including each page of a query or scan and each batch of a batch write.
+//
+// It is safe for concurrent use, so it can be shared by operations running in parallel for the same request.
+type CapacityAccount struct {
+	mu     sync.Mutex
+	total  Capacity
+	tables map[string]*TableCapacity
+}
+
+// WithCapacityAccount attaches a new CapacityAccount to the context, store operations using the returned context add the
+// capacity they consume to the account.
+//
+//	ctx, account := dynastorev2.WithCapacityAccount(ctx)
+//
+//	// perform store operations with ctx
+//
+//	fmt.Println("request consumed", account.Total().ReadUnits, "RCU")
+func WithCapacityAccount(ctx context.Context) (context.Context, *CapacityAccount) {
+	account := &CapacityAccount{
+		tables: make(map[string]*TableCapacity),
+	}
+
+	return context.WithValue(ctx, capacityAccountCtxKey, account), account
+}
+
+// CapacityAccountFromContext returns the CapacityAccount attached to the context, if there isn't one it returns nil.
+func CapacityAccountFromContext(ctx context.Context) *CapacityAccount {
+	account, _ := ctx.Value(capacityAccountCtxKey).(*CapacityAccount)
+	return account
+}
+
+// Total returns the capacity consumed across all tables
+func (a *CapacityAccount) Total() Capacity {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.total
+}
+
+// Tables returns the capacity consumed by each table, keyed by table name
+func (a *CapacityAccount) Tables() map[string]TableCapacity {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	tables := make(map[string]TableCapacity, len(a.tables))
+
+	for name, tc := range a.tables {
+		indexes := make(map[string]Capacity, len(tc.Indexes))
+		for index, c := range tc.Indexes {
+			indexes[index] = c
+		}
+
+		tables[name] = TableCapacity{Capacity: tc.Capacity, Indexes: indexes}
+	}
+
+	return tables
+}
+
+// add records the capacity consumed by the call described in the response event
+func (a *CapacityAccount) add(event *ResponseEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, cc := range event.ConsumedCapacity {
+		name := aws.ToString(cc.TableName)
+		if name == "" {
+			name = event.Request.Table
+		}
+
+		tc, ok := a.tables[name]
+		if !ok {
+			tc = &TableCapacity{Indexes: make(map[string]Capacity)}
+			a.tables[name] = tc
+		}
+
+		used := splitCapacity(event.Request.Write, cc.ReadCapacityUnits, cc.WriteCapacityUnits, cc.CapacityUnits)
+
+		tc.addUnits(used)
+		a.total.addUnits(used)
+
+		indexes := make(map[string]types.Capacity, len(cc.GlobalSecondaryIndexes)+len(cc.LocalSecondaryIndexes))
+
+		for index, c := range cc.GlobalSecondaryIndexes {
+			indexes[index] = c
+		}
+
+		for index, c := range cc.LocalSecondaryIndexes {
+			indexes[index] = c
+		}
+
+		// without a breakdown by index all the capacity consumed by a call to an index is attributed to it
+		if len(indexes) == 0 && event.Request.Index != "" {
+			indexes[event.Request.Index] = types.Capacity{CapacityUnits: cc.CapacityUnits}
+		}
+
+		for index, c := range indexes {
+			ic := tc.Indexes[index]
+			ic.addUnits(splitCapacity(event.Request.Write, c.ReadCapacityUnits, c.WriteCapacityUnits, c.CapacityUnits))
+			tc.Indexes[index] = ic
+		}
+	}
+}
+
+func (c *Capacity) addUnits(used Capacity) {
+	c.ReadUnits += used.ReadUnits
+	c.WriteUnits += used.WriteUnits
+}
+
+// splitCapacity uses the read and write units if they were returned, otherwise the total units are attributed to reads
+// or writes based on the type of call
+func splitCapacity(write bool, readUnits, writeUnits, units *float64) Capacity {
+	if readUnits != nil || writeUnits != nil {
+		return Capacity{ReadUnits: aws.ToFloat64(readUnits), WriteUnits: aws.ToFloat64(writeUnits)}
+	}
+
+	if write {
+		return Capacity{WriteUnits: aws.ToFloat64(units)}
+	}
+
+	return Capacity{ReadUnits: aws.ToFloat64(units)}
+}
+
+// totalCapacity returns the capacity summed across the calls made by an operation, or nil if consumed capacity isn't
+// returned by the store
+func (t *Store[P, S, V]) totalCapacity(total *types.ConsumedCapacity) *types.ConsumedCapacity {
+	if t.storeOptions.consumedCapacity == types.ReturnConsumedCapacityNone {
+		return nil
+	}
+
+	return total
+}
+
+// addCapacity sums the capacity units consumed by many calls into the total, including the breakdown by table and
+// index when it is returned
+func addCapacity(total *types.ConsumedCapacity, used ...types.ConsumedCapacity) {
+	for _, cc := range used {
+		total.CapacityUnits = sumUnits(total.CapacityUnits, cc.CapacityUnits)
+		total.ReadCapacityUnits = sumUnits(total.ReadCapacityUnits, cc.ReadCapacityUnits)
+		total.WriteCapacityUnits = sumUnits(total.WriteCapacityUnits, cc.WriteCapacityUnits)
+
+		if cc.Table != nil {
+			if total.Table == nil {
+				total.Table = &types.Capacity{}
+			}
+
+			*total.Table = sumCapacity(*total.Table, *cc.Table)
+		}
+
+		total.GlobalSecondaryIndexes = sumIndexCapacity(total.GlobalSecondaryIndexes, cc.GlobalSecondaryIndexes)
+		total.LocalSecondaryIndexes = sumIndexCapacity(total.LocalSecondaryIndexes, cc.LocalSecondaryIndexes)
+	}
+}
+
+func sumIndexCapacity(total, used map[string]types.Capacity) map[string]types.Capacity {
+	if len(used) == 0 {
+		return total
+	}
+
+	if total == nil {
+		total = make(map[string]types.Capacity, len(used))
+	}
+
+	for index, c := range used {
+		total[index] = sumCapacity(total[index], c)
+	}
+
+	return total
+}
+
+func sumCapacity(total, used types.Capacity) types.Capacity {
+	return types.Capacity{
+		CapacityUnits:      sumUnits(total.CapacityUnits, used.CapacityUnits),
+		ReadCapacityUnits:  sumUnits(total.ReadCapacityUnits, used.ReadCapacityUnits),
+		WriteCapacityUnits: sumUnits(total.WriteCapacityUnits, used.WriteCapacityUnits),
+	}
+}
+
+// sumUnits adds the units leaving the result nil if neither were returned
+func sumUnits(total, used *float64) *float64 {
+	if used == nil {
+		return total
+	}
+
+	return aws.Float64(aws.ToFloat64(total) + *used)
+}
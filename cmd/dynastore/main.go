@@ -0,0 +1,430 @@
+// Command dynastore inspects and edits records in a table managed by dynastorev2, keeping the payload and version
+// conventions used by the store intact.
+//
+// Usage:
+//
+//	dynastore [-table name] [-endpoint url] [store flags] <command> [flags] <args>
+//
+// Commands:
+//
+//	get <partition> <sort>              print a record
+//	create <partition> <sort> <value>   create a record, the value is JSON or - to read from stdin
+//	update <partition> <sort> <value>   update a record, the value is JSON or - to read from stdin
+//	delete <partition> <sort>           delete a record
+//	list <partition> <prefix>           list records with a sort key starting with the prefix
+//
+// The store flags must match the options used by the applications which write to the table, for example -soft-delete
+// for tables using soft deletes. Records are read before they are updated or deleted, so records the tool can't decode,
+// such as encrypted records when no keys are provided, are left unchanged.
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+type store = dynastorev2.Store[string, string, any]
+
+type command struct {
+	args  string
+	usage string
+	run   func(ctx context.Context, st *store, flags *flag.FlagSet, args []string, stdin io.Reader, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"get":    {args: "<partition> <sort>", usage: "print a record", run: getCmd},
+	"create": {args: "<partition> <sort> <value>", usage: "create a record, the value is JSON or - to read from stdin", run: createCmd},
+	"update": {args: "<partition> <sort> <value>", usage: "update a record, the value is JSON or - to read from stdin", run: updateCmd},
+	"delete": {args: "<partition> <sort>", usage: "delete a record", run: deleteCmd},
+	"list":   {args: "<partition> <prefix>", usage: "list records with a sort key starting with the prefix", run: listCmd},
+}
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dynastore:", err)
+		os.Exit(1)
+	}
+}
+
+// storeFlags holds the flags used to configure the store to match the applications which write to the table
+type storeFlags struct {
+	softDelete    time.Duration
+	keysFile      string
+	keyID         string
+	blobDir       string
+	blobThreshold int
+	schemaVersion int64
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	global := flag.NewFlagSet("dynastore", flag.ContinueOnError)
+	global.SetOutput(stderr)
+
+	table := global.String("table", os.Getenv("DYNASTORE_TABLE"), "name of the table, defaults to $DYNASTORE_TABLE")
+	endpoint := global.String("endpoint", os.Getenv("DYNASTORE_ENDPOINT"), "endpoint of DynamoDB, for example dynamodb-local, defaults to $DYNASTORE_ENDPOINT")
+
+	var sf storeFlags
+
+	global.DurationVar(&sf.softDelete, "soft-delete", 0, "retention of soft deleted records, required for tables using soft deletes")
+	global.StringVar(&sf.keysFile, "keys", "", "JSON file mapping key ids to base64 encoded keys used to encrypt payloads")
+	global.StringVar(&sf.keyID, "key-id", "", "id of the key in the keys file used to encrypt new payloads")
+	global.StringVar(&sf.blobDir, "blob-dir", "", "directory of the file blob store holding offloaded payloads")
+	global.IntVar(&sf.blobThreshold, "blob-threshold", 0, "size in bytes above which payloads are offloaded to the blob store, defaults to 350KB")
+	global.Int64Var(&sf.schemaVersion, "schema-version", 0, "schema version recorded with payloads, records with an older version can't be read as they require an upgrade")
+
+	global.Usage = func() {
+		fmt.Fprintln(global.Output(), "usage: dynastore [flags] <command> [command flags] <args>")
+		fmt.Fprintln(global.Output(), "\ncommands:")
+
+		for _, name := range []string{"get", "create", "update", "delete", "list"} {
+			fmt.Fprintf(global.Output(), "  %-7s %-28s %s\n", name, commands[name].args, commands[name].usage)
+		}
+
+		fmt.Fprintln(global.Output(), "\nflags:")
+		global.PrintDefaults()
+	}
+
+	if err := global.Parse(args); err != nil {
+		return errUsage
+	}
+
+	cmd, ok := commands[global.Arg(0)]
+	if !ok {
+		global.Usage()
+		return errUsage
+	}
+
+	if *table == "" {
+		fmt.Fprintln(global.Output(), "dynastore: a table name is required")
+		return errUsage
+	}
+
+	options, err := storeOptions(sf)
+	if err != nil {
+		fmt.Fprintln(global.Output(), "dynastore:", err)
+		return errUsage
+	}
+
+	flags := flag.NewFlagSet(global.Arg(0), flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: dynastore %s [flags] %s\n\n%s\n\nflags:\n", global.Arg(0), cmd.args, cmd.usage)
+		flags.PrintDefaults()
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load aws config: %w", err)
+	}
+
+	client := dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		if *endpoint != "" {
+			o.BaseEndpoint = aws.String(*endpoint)
+		}
+	})
+
+	return cmd.run(ctx, dynastorev2.New(client, *table, options...), flags, global.Args()[1:], stdin, stdout)
+}
+
+// storeOptions builds the options used to configure the store from the flags
+func storeOptions(sf storeFlags) ([]dynastorev2.StoreOption[string, string, any], error) {
+	var options []dynastorev2.StoreOption[string, string, any]
+
+	if sf.softDelete > 0 {
+		options = append(options, dynastorev2.WithSoftDelete[string, string, any](sf.softDelete))
+	}
+
+	if sf.keysFile != "" {
+		kp, err := readKeys(sf.keysFile, sf.keyID)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, dynastorev2.WithEncryption[string, string, any](kp))
+	}
+
+	if sf.blobDir != "" {
+		options = append(options, dynastorev2.WithBlobStore[string, string, any](dynastorev2.NewFileBlobStore(sf.blobDir), sf.blobThreshold))
+	}
+
+	if sf.schemaVersion > 0 {
+		// without upgrade functions records with an older schema version fail to be read rather than being misread
+		options = append(options, dynastorev2.WithSchemaVersion[string, string, any](sf.schemaVersion, nil))
+	}
+
+	return options, nil
+}
+
+// readKeys reads the keys file into a static key provider which encrypts new payloads with the provided key id
+func readKeys(path, keyID string) (dynastorev2.KeyProvider, error) {
+	if keyID == "" {
+		return nil, errors.New("-key-id is required with -keys")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys: %w", err)
+	}
+
+	encoded := make(map[string]string)
+
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("failed to parse keys: %w", err)
+	}
+
+	keys := make(map[string][]byte, len(encoded))
+
+	for id, key := range encoded {
+		keys[id], err = base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key %q: %w", id, err)
+		}
+	}
+
+	return dynastorev2.NewStaticKeyProvider(keyID, keys)
+}
+
+func getCmd(ctx context.Context, st *store, flags *flag.FlagSet, args []string, _ io.Reader, stdout io.Writer) error {
+	consistent := flags.Bool("consistent", false, "use a consistent read")
+	deleted := flags.Bool("deleted", false, "include soft deleted records")
+
+	if err := parseArgs(flags, args, 2); err != nil {
+		return err
+	}
+
+	_, record, err := st.GetRecord(ctx, flags.Arg(0), flags.Arg(1), st.ReadWithConsistentRead(*consistent), st.ReadWithDeleted(*deleted))
+	if err != nil {
+		return err
+	}
+
+	return printRecord(stdout, record)
+}
+
+func createCmd(ctx context.Context, st *store, flags *flag.FlagSet, args []string, stdin io.Reader, stdout io.Writer) error {
+	ttl := flags.Duration("ttl", 0, "time to live of the record, for example 24h")
+	fields := flags.String("fields", "", "extra fields stored alongside the payload as a JSON object")
+
+	if err := parseArgs(flags, args, 3); err != nil {
+		return err
+	}
+
+	value, err := readValue(flags.Arg(2), stdin)
+	if err != nil {
+		return err
+	}
+
+	options := []dynastorev2.WriteOption[string, string, any]{st.WriteWithTTL(*ttl)}
+
+	if *fields != "" {
+		extraFields := make(map[string]any)
+
+		if err := json.Unmarshal([]byte(*fields), &extraFields); err != nil {
+			return fmt.Errorf("failed to parse fields: %w", err)
+		}
+
+		options = append(options, st.WriteWithExtraFields(extraFields))
+	}
+
+	res, err := st.Create(ctx, flags.Arg(0), flags.Arg(1), value, options...)
+	if err != nil {
+		return err
+	}
+
+	return printVersion(stdout, res.Version)
+}
+
+func updateCmd(ctx context.Context, st *store, flags *flag.FlagSet, args []string, stdin io.Reader, stdout io.Writer) error {
+	version := flags.Int64("version", 0, "version the record must match to be updated, use this to avoid overwriting concurrent edits")
+	ttl := flags.Duration("ttl", 0, "time to live of the record, for example 24h")
+	clearTTL := flags.Bool("clear-ttl", false, "remove the time to live so the record never expires")
+
+	if err := parseArgs(flags, args, 3); err != nil {
+		return err
+	}
+
+	value, err := readValue(flags.Arg(2), stdin)
+	if err != nil {
+		return err
+	}
+
+	current, err := readRecord(ctx, st, flags.Arg(0), flags.Arg(1), *version)
+	if err != nil {
+		return err
+	}
+
+	options := []dynastorev2.WriteOption[string, string, any]{st.WriteWithVersion(current.Version), st.WriteWithTTL(*ttl)}
+
+	if *clearTTL {
+		options = append(options, st.WriteWithTTLCleared())
+	}
+
+	res, err := st.Update(ctx, flags.Arg(0), flags.Arg(1), value, options...)
+	if err != nil {
+		return err
+	}
+
+	return printVersion(stdout, res.Version)
+}
+
+func deleteCmd(ctx context.Context, st *store, flags *flag.FlagSet, args []string, _ io.Reader, stdout io.Writer) error {
+	version := flags.Int64("version", 0, "version the record must match to be deleted, use this to avoid removing concurrent edits")
+
+	if err := parseArgs(flags, args, 2); err != nil {
+		return err
+	}
+
+	current, err := readRecord(ctx, st, flags.Arg(0), flags.Arg(1), *version)
+	if err != nil {
+		return err
+	}
+
+	res, value, err := st.DeleteAndReturn(ctx, flags.Arg(0), flags.Arg(1), st.DeleteWithVersion(current.Version))
+	if err != nil {
+		return err
+	}
+
+	// the delete is conditional on the version read so the expiry and fields of that read are what was removed
+	current.Version = res.Version
+	current.Value = value
+
+	// print the deleted record so it can be recreated if it was removed by mistake
+	return printRecord(stdout, current)
+}
+
+func listCmd(ctx context.Context, st *store, flags *flag.FlagSet, args []string, _ io.Reader, stdout io.Writer) error {
+	index := flags.String("index", "", "name of the index to query")
+	indexPartition := flags.String("index-partition", "", "name of the partition key attribute of the index")
+	indexSort := flags.String("index-sort", "", "name of the sort key attribute of the index")
+	limit := flags.Int("limit", 0, "maximum number of records to read")
+	reverse := flags.Bool("reverse", false, "list records in descending order of sort key")
+	next := flags.String("next", "", "token returned by a previous list to read the next page")
+	deleted := flags.Bool("deleted", false, "include soft deleted records")
+
+	if err := parseArgs(flags, args, 2); err != nil {
+		return err
+	}
+
+	options := []dynastorev2.ReadOption[string, string]{
+		st.ReadWithLimit(int32(*limit)),
+		st.ReadWithReverseSortResults(*reverse),
+		st.ReadWithLastEvaluatedKey(*next),
+		st.ReadWithDeleted(*deleted),
+	}
+
+	if *index != "" {
+		if *indexPartition == "" || *indexSort == "" {
+			fmt.Fprintln(flags.Output(), "dynastore: -index-partition and -index-sort are required with -index")
+			return errUsage
+		}
+
+		options = append(options, st.ReadWithIndex(*index, *indexPartition, *indexSort))
+	}
+
+	res, records, err := st.ListRecordsBySortKeyPrefix(ctx, flags.Arg(0), flags.Arg(1), options...)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if err := printRecord(stdout, record); err != nil {
+			return err
+		}
+	}
+
+	if res.LastEvaluatedKey != "" {
+		fmt.Fprintf(flags.Output(), "more records available, continue with: -next %s\n", res.LastEvaluatedKey)
+	}
+
+	return nil
+}
+
+// readRecord reads the record to check it can be decoded before it is modified, the write must match the version of
+// the record returned so changes made since it was read aren't overwritten. If a version is provided the record must
+// match it.
+func readRecord(ctx context.Context, st *store, partitionKey, sortKey string, version int64) (dynastorev2.Record[string, string, any], error) {
+	_, record, err := st.GetRecord(ctx, partitionKey, sortKey, st.ReadWithConsistentRead(true))
+	if err != nil {
+		return dynastorev2.Record[string, string, any]{}, err
+	}
+
+	if version > 0 && version != record.Version {
+		return dynastorev2.Record[string, string, any]{}, fmt.Errorf("record is at version %d not %d", record.Version, version)
+	}
+
+	return record, nil
+}
+
+func parseArgs(flags *flag.FlagSet, args []string, count int) error {
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if flags.NArg() != count {
+		flags.Usage()
+		return errUsage
+	}
+
+	return nil
+}
+
+// readValue parses the JSON value from the argument, or from stdin if the argument is -
+func readValue(arg string, stdin io.Reader) (any, error) {
+	data := []byte(arg)
+
+	if arg == "-" {
+		var err error
+
+		data, err = io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read value: %w", err)
+		}
+	}
+
+	var value any
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to parse value as JSON: %w", err)
+	}
+
+	return value, nil
+}
+
+func printRecord(w io.Writer, record dynastorev2.Record[string, string, any]) error {
+	// payloads stored as JSON encoded bytes are printed as JSON rather than base64
+	if data, ok := record.Value.([]byte); ok && json.Valid(data) {
+		record.Value = json.RawMessage(data)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(&record)
+}
+
+func printVersion(w io.Writer, version int64) error {
+	_, err := fmt.Fprintf(w, "version %d\n", version)
+	return err
+}
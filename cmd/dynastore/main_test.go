@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wolfeidau/dynastorev2"
+)
+
+const (
+	recordItem    = `{"id":{"S":"pk"},"name":{"S":"sk"},"version":{"N":"3"},"payload":{"M":{"title":{"S":"hello"}}}}`
+	expiringItem  = `{"id":{"S":"pk"},"name":{"S":"sk"},"version":{"N":"3"},"expires":{"N":"1704067200"},"owner":{"S":"bob"},"payload":{"M":{"title":{"S":"hello"}}}}`
+	encryptedItem = `{"id":{"S":"pk"},"name":{"S":"sk"},"version":{"N":"3"},"payload":{"B":"AAAA"},"key_id":{"S":"key1"},"data_key":{"B":"AAAA"}}`
+)
+
+// fakeDynamoDB serves canned responses for each DynamoDB operation in order, recording the requests it receives
+type fakeDynamoDB struct {
+	mu        sync.Mutex
+	responses map[string][]string
+	requests  []fakeRequest
+}
+
+type fakeRequest struct {
+	operation string
+	body      string
+}
+
+func (f *fakeDynamoDB) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.")
+
+	body, _ := io.ReadAll(r.Body)
+	f.requests = append(f.requests, fakeRequest{operation: operation, body: string(body)})
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+
+	responses := f.responses[operation]
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"__type":"com.amazon.coral.validate#ValidationException","message":"unexpected `+operation+`"}`)
+
+		return
+	}
+
+	f.responses[operation] = responses[1:]
+
+	_, _ = io.WriteString(w, responses[0])
+}
+
+func (f *fakeDynamoDB) operations() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var operations []string
+
+	for _, req := range f.requests {
+		operations = append(operations, req.operation)
+	}
+
+	return operations
+}
+
+func (f *fakeDynamoDB) request(operation string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, req := range f.requests {
+		if req.operation == operation {
+			return req.body
+		}
+	}
+
+	return ""
+}
+
+func setupEnv(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("DYNASTORE_TABLE", "")
+	t.Setenv("DYNASTORE_ENDPOINT", "")
+}
+
+func writeKeys(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	data, err := json.Marshal(map[string]string{
+		"key1": base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func assertContains(t *testing.T, s, substr string) {
+	t.Helper()
+
+	if !strings.Contains(s, substr) {
+		t.Errorf("expected %q to contain %q", s, substr)
+	}
+}
+
+func assertNotContains(t *testing.T, s, substr string) {
+	t.Helper()
+
+	if strings.Contains(s, substr) {
+		t.Errorf("expected %q not to contain %q", s, substr)
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	setupEnv(t)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStderr string
+	}{
+		{name: "no command", args: nil, wantStderr: "usage: dynastore"},
+		{name: "unknown command", args: []string{"-table", "test-table", "unknown"}, wantStderr: "usage: dynastore"},
+		{name: "missing table", args: []string{"get", "pk", "sk"}, wantStderr: "a table name is required"},
+		{name: "missing args", args: []string{"-table", "test-table", "get", "pk"}, wantStderr: "usage: dynastore get"},
+		{name: "too many args", args: []string{"-table", "test-table", "delete", "pk", "sk", "extra"}, wantStderr: "usage: dynastore delete"},
+		{name: "unknown flag", args: []string{"-table", "test-table", "get", "-unknown", "pk", "sk"}, wantStderr: "flag provided but not defined"},
+		{name: "keys without key id", args: []string{"-table", "test-table", "-keys", "keys.json", "get", "pk", "sk"}, wantStderr: "-key-id is required with -keys"},
+		{name: "missing keys file", args: []string{"-table", "test-table", "-keys", filepath.Join(t.TempDir(), "keys.json"), "-key-id", "key1", "get", "pk", "sk"}, wantStderr: "failed to read keys"},
+		{name: "unknown key id", args: []string{"-table", "test-table", "-keys", writeKeys(t), "-key-id", "key2", "get", "pk", "sk"}, wantStderr: `current key "key2" not found`},
+		{name: "index without keys", args: []string{"-table", "test-table", "list", "-index", "idx_global_1", "pk", "sk"}, wantStderr: "-index-partition and -index-sort are required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+
+			err := run(context.Background(), tt.args, strings.NewReader(""), stdout, stderr)
+			if !errors.Is(err, errUsage) {
+				t.Fatalf("expected usage error got %v", err)
+			}
+
+			assertContains(t, stderr.String(), tt.wantStderr)
+
+			if stdout.Len() > 0 {
+				t.Errorf("expected no output got %q", stdout.String())
+			}
+		})
+	}
+}
+
+func TestCommands(t *testing.T) {
+	setupEnv(t)
+
+	keys := writeKeys(t)
+
+	tests := []struct {
+		name           string
+		args           []string
+		stdin          string
+		responses      map[string][]string
+		wantOperations []string
+		wantStdout     []string
+		wantStderr     string
+		wantErr        error
+		wantErrText    string
+		check          func(t *testing.T, fake *fakeDynamoDB)
+	}{
+		{
+			name:           "get prints the record",
+			args:           []string{"get", "pk", "sk"},
+			responses:      map[string][]string{"GetItem": {`{"Item":` + recordItem + `}`}},
+			wantOperations: []string{"GetItem"},
+			wantStdout:     []string{`"partition_key": "pk"`, `"version": 3`, `"title": "hello"`},
+		},
+		{
+			name:           "get fails for encrypted records without keys",
+			args:           []string{"get", "pk", "sk"},
+			responses:      map[string][]string{"GetItem": {`{"Item":` + encryptedItem + `}`}},
+			wantOperations: []string{"GetItem"},
+			wantErr:        dynastorev2.ErrKeyProviderMissing,
+		},
+		{
+			name:           "create writes the value",
+			args:           []string{"create", "pk", "sk", `{"title":"hello"}`},
+			responses:      map[string][]string{"UpdateItem": {`{"Attributes":{"version":{"N":"1"}}}`}},
+			wantOperations: []string{"UpdateItem"},
+			wantStdout:     []string{"version 1\n"},
+		},
+		{
+			name:           "create reads the value from stdin",
+			args:           []string{"create", "pk", "sk", "-"},
+			stdin:          `{"title":"from stdin"}`,
+			responses:      map[string][]string{"UpdateItem": {`{"Attributes":{"version":{"N":"1"}}}`}},
+			wantOperations: []string{"UpdateItem"},
+			wantStdout:     []string{"version 1\n"},
+			check: func(t *testing.T, fake *fakeDynamoDB) {
+				assertContains(t, fake.request("UpdateItem"), "from stdin")
+			},
+		},
+		{
+			name:           "create with soft delete replaces tombstones",
+			args:           []string{"-soft-delete", "24h", "create", "pk", "sk", `{"title":"hello"}`},
+			responses:      map[string][]string{"PutItem": {`{}`}},
+			wantOperations: []string{"PutItem"},
+			wantStdout:     []string{"version 1\n"},
+		},
+		{
+			name:           "create with encryption",
+			args:           []string{"-keys", keys, "-key-id", "key1", "create", "pk", "sk", `{"title":"hello"}`},
+			responses:      map[string][]string{"UpdateItem": {`{"Attributes":{"version":{"N":"1"}}}`}},
+			wantOperations: []string{"UpdateItem"},
+			wantStdout:     []string{"version 1\n"},
+			check: func(t *testing.T, fake *fakeDynamoDB) {
+				body := fake.request("UpdateItem")
+				assertContains(t, body, `"key1"`)
+				assertNotContains(t, body, "hello")
+			},
+		},
+		{
+			name: "update is conditioned on the version read",
+			args: []string{"update", "pk", "sk", `{"title":"updated"}`},
+			responses: map[string][]string{
+				"GetItem":    {`{"Item":` + recordItem + `}`},
+				"UpdateItem": {`{"Attributes":{"version":{"N":"4"}}}`},
+			},
+			wantOperations: []string{"GetItem", "UpdateItem"},
+			wantStdout:     []string{"version 4\n"},
+			check: func(t *testing.T, fake *fakeDynamoDB) {
+				assertContains(t, fake.request("GetItem"), `"ConsistentRead":true`)
+				assertContains(t, fake.request("UpdateItem"), `{"N":"3"}`)
+			},
+		},
+		{
+			name:           "update fails when the version doesn't match",
+			args:           []string{"update", "-version", "2", "pk", "sk", `{"title":"updated"}`},
+			responses:      map[string][]string{"GetItem": {`{"Item":` + recordItem + `}`}},
+			wantOperations: []string{"GetItem"},
+			wantErrText:    "record is at version 3 not 2",
+		},
+		{
+			name:           "update leaves encrypted records unchanged without keys",
+			args:           []string{"update", "pk", "sk", `{"title":"updated"}`},
+			responses:      map[string][]string{"GetItem": {`{"Item":` + encryptedItem + `}`}},
+			wantOperations: []string{"GetItem"},
+			wantErr:        dynastorev2.ErrKeyProviderMissing,
+		},
+		{
+			name:           "update of a missing record",
+			args:           []string{"update", "pk", "sk", `{"title":"updated"}`},
+			responses:      map[string][]string{"GetItem": {`{}`}},
+			wantOperations: []string{"GetItem"},
+			wantErr:        dynastorev2.ErrKeyNotExists,
+		},
+		{
+			name: "delete prints the deleted record",
+			args: []string{"delete", "pk", "sk"},
+			responses: map[string][]string{
+				"GetItem":    {`{"Item":` + expiringItem + `}`},
+				"DeleteItem": {`{"Attributes":` + expiringItem + `}`},
+			},
+			wantOperations: []string{"GetItem", "DeleteItem"},
+			wantStdout:     []string{`"sort_key": "sk"`, `"expires": "2024-01-01T00:00:00Z"`, `"owner": "bob"`, `"title": "hello"`},
+		},
+		{
+			name: "delete with soft delete leaves a tombstone",
+			args: []string{"-soft-delete", "24h", "delete", "pk", "sk"},
+			responses: map[string][]string{
+				"GetItem":    {`{"Item":` + recordItem + `}`},
+				"UpdateItem": {`{"Attributes":` + recordItem + `}`},
+			},
+			wantOperations: []string{"GetItem", "UpdateItem"},
+			wantStdout:     []string{`"title": "hello"`},
+			check: func(t *testing.T, fake *fakeDynamoDB) {
+				assertContains(t, fake.request("UpdateItem"), "deleted_at")
+			},
+		},
+		{
+			name:           "delete leaves encrypted records unchanged without keys",
+			args:           []string{"delete", "pk", "sk"},
+			responses:      map[string][]string{"GetItem": {`{"Item":` + encryptedItem + `}`}},
+			wantOperations: []string{"GetItem"},
+			wantErr:        dynastorev2.ErrKeyProviderMissing,
+		},
+		{
+			name: "list prints each record and the next token",
+			args: []string{"list", "-limit", "1", "pk", "s"},
+			responses: map[string][]string{
+				"Query": {`{"Items":[` + recordItem + `],"Count":1,"LastEvaluatedKey":{"id":{"S":"pk"},"name":{"S":"sk"}}}`},
+			},
+			wantOperations: []string{"Query"},
+			wantStdout:     []string{`"title": "hello"`},
+			wantStderr:     "more records available, continue with: -next",
+			check: func(t *testing.T, fake *fakeDynamoDB) {
+				assertContains(t, fake.request("Query"), `"Limit":1`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeDynamoDB{responses: tt.responses}
+
+			srv := httptest.NewServer(fake)
+			defer srv.Close()
+
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+			args := append([]string{"-table", "test-table", "-endpoint", srv.URL}, tt.args...)
+
+			err := run(context.Background(), args, strings.NewReader(tt.stdin), stdout, stderr)
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v got %v", tt.wantErr, err)
+				}
+			case tt.wantErrText != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrText) {
+					t.Fatalf("expected error containing %q got %v", tt.wantErrText, err)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if operations := fake.operations(); !slices.Equal(tt.wantOperations, operations) {
+				t.Errorf("expected operations %v got %v", tt.wantOperations, operations)
+			}
+
+			for _, want := range tt.wantStdout {
+				assertContains(t, stdout.String(), want)
+			}
+
+			if tt.wantStderr != "" {
+				assertContains(t, stderr.String(), tt.wantStderr)
+			}
+
+			if tt.check != nil {
+				tt.check(t, fake)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package dynastorev2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	dexp "github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Increment atomically adds the delta to the counter attribute of the record in DynamoDB using the provided partition
+// and sort keys, returning the new value of the counter. A missing counter attribute is treated as zero.
+//
+// Notes:
+// 1. Counters are stored alongside the payload and don't increment the version of the record.
+// 2. This will use a condition to ensure the specified partition and sort keys exist in DynamoDB, use WriteWithCreateIfMissing to create the record instead.
+func (t *Store[P, S, V]) Increment(ctx context.Context, partitionKey P, sortKey S, attr string, delta int64, options ...WriteOption[P, S, V]) (*OperationResult, int64, error) {
+	res, counters, err := t.incrementCounters(ctx, "Increment", partitionKey, sortKey, map[string]int64{attr: delta}, options...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res, counters[attr], nil
+}
+
+// IncrementMany atomically adds each delta to the matching counter attribute of the record in DynamoDB using the provided
+// partition and sort keys, returning the new values of the counters.
+//
+// See Increment for more details.
+func (t *Store[P, S, V]) IncrementMany(ctx context.Context, partitionKey P, sortKey S, deltas map[string]int64, options ...WriteOption[P, S, V]) (*OperationResult, map[string]int64, error) {
+	return t.incrementCounters(ctx, "IncrementMany", partitionKey, sortKey, deltas, options...)
+}
+
+func (t *Store[P, S, V]) incrementCounters(ctx context.Context, name string, partitionKey P, sortKey S, deltas map[string]int64, options ...WriteOption[P, S, V]) (*OperationResult, map[string]int64, error) {
+	ctx = setOperationDetails(ctx, name, partitionKey, sortKey)
+
+	defaultOpts := t.defaultWriteOptions()
+	ApplyWriteOptions(defaultOpts, options...)
+
+	if err := t.checkCondition(defaultOpts.condition); err != nil {
+		return nil, nil, err
+	}
+
+	if len(deltas) == 0 {
+		return nil, nil, errors.New("dynastorev2: no counters provided to increment")
+	}
+
+	var update dexp.UpdateBuilder
+
+	for attr, delta := range deltas {
+		if t.isReservedField(attr) {
+			return nil, nil, fmt.Errorf("%w: %s", ErrReservedField, attr)
+		}
+
+		update = update.Add(dexp.Name(attr), dexp.Value(delta))
+	}
+
+	update, err := t.addWriteFields(update, defaultOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("dynastorev2: failed to build update: %w", err)
+	}
+
+	builder := dexp.NewBuilder().WithUpdate(update)
+
+	var updateCondition dexp.ConditionBuilder
+
+	if !defaultOpts.createIfMissing {
+		// assign a condition which requires the record to existing before being updated
+		updateCondition = t.existsCondition()
+	}
+
+	if defaultOpts.version > 0 {
+		updateCondition = andConditions(updateCondition, dexp.Equal(dexp.Name(t.fields.versionName), dexp.Value(defaultOpts.version)))
+	}
+
+	updateCondition = andConditions(updateCondition, defaultOpts.condition)
+
+	if updateCondition.IsSet() {
+		builder = builder.WithCondition(updateCondition)
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, nil, fmt.Errorf("dynastorev2: failed to build update expression: %w", err)
+	}
+
+	// only the counters, extra fields and TTL are updated so only return those values
+	result, err := t.doUpdate(ctx, partitionKey, sortKey, expr, types.ReturnValueUpdatedNew, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	counters := make(map[string]int64, len(deltas))
+
+	for attr := range deltas {
+		var value int64
+
+		err := attributevalue.Unmarshal(result.Attributes[attr], &value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("dynastorev2: failed to extract counter attribute: %w", err)
+		}
+
+		counters[attr] = value
+	}
+
+	return &OperationResult{
+		ConsumedCapacity: result.ConsumedCapacity,
+	}, counters, nil
+}
@@ -32,6 +32,27 @@ const (
 
 	// DefaultPayloadAttribute this is the default attribute name containing the encoded payload of the record
 	DefaultPayloadAttribute = "payload"
+
+	// DefaultKeyIDAttribute this is the default name for the attribute containing the id of the key used to wrap the data key of an encrypted payload
+	DefaultKeyIDAttribute = "key_id"
+
+	// DefaultDataKeyAttribute this is the default name for the attribute containing the wrapped data key of an encrypted payload
+	DefaultDataKeyAttribute = "data_key"
+
+	// DefaultBlobKeyAttribute this is the default name for the attribute containing the key of a payload offloaded to the blob store
+	DefaultBlobKeyAttribute = "blob_key"
+
+	// DefaultBlobChecksumAttribute this is the default name for the attribute containing the SHA-256 checksum of a payload offloaded to the blob store
+	DefaultBlobChecksumAttribute = "blob_checksum"
+
+	// DefaultSchemaVersionAttribute this is the default name for the attribute containing the schema version of the payload
+	DefaultSchemaVersionAttribute = "schema_version"
+
+	// DefaultDeletedAtAttribute this is the default name for the attribute containing the time a record was soft deleted
+	DefaultDeletedAtAttribute = "deleted_at"
+
+	// DefaultDeletedExpiresAttribute this is the default name for the attribute containing the expiry of a soft deleted record before it was deleted, so it can be restored
+	DefaultDeletedExpiresAttribute = "deleted_expires"
 )
 
 var (
@@ -41,8 +62,35 @@ var (
 	// ErrDeleteFailedKeyNotExists delete failed due to constraint added which checks the record exists when deleting
 	ErrDeleteFailedKeyNotExists = errors.New("dynastorev2: delete failed as the partition and sort keys didn't exist in the table")
 
+	// ErrDeleteFailedConditionCheck delete failed as the record exists but the version or condition provided didn't match
+	ErrDeleteFailedConditionCheck = errors.New("dynastorev2: delete failed as the record didn't match the version or condition")
+
 	// ErrKeyNotExists get failed due to partition and sort keys didn't exist in the table
 	ErrKeyNotExists = errors.New("dynastorev2: get failed as the partition and sort keys didn't exist in the table")
+
+	// ErrKeyProviderMissing read failed as the payload is encrypted and no key provider is configured for the store
+	ErrKeyProviderMissing = errors.New("dynastorev2: payload is encrypted but no key provider is configured")
+
+	// ErrBlobStoreMissing read failed as the payload was offloaded and no blob store is configured for the store
+	ErrBlobStoreMissing = errors.New("dynastorev2: payload is offloaded but no blob store is configured")
+
+	// ErrBlobChecksumMismatch read failed as the offloaded payload doesn't match the checksum stored in the item
+	ErrBlobChecksumMismatch = errors.New("dynastorev2: offloaded payload doesn't match the checksum stored in the item")
+
+	// ErrSchemaUpgradeMissing read failed as no upgrade function is registered for the schema version of the payload
+	ErrSchemaUpgradeMissing = errors.New("dynastorev2: no schema upgrade registered")
+
+	// ErrPayloadFieldUpdateUnsupported field update failed as the payload is encrypted or may be offloaded so individual fields can't be updated
+	ErrPayloadFieldUpdateUnsupported = errors.New("dynastorev2: payload fields can't be updated when encryption or blob offload is enabled")
+
+	// ErrPayloadConditionUnsupported write or delete failed as the condition refers to fields within the payload, which are encrypted or may be offloaded
+	ErrPayloadConditionUnsupported = errors.New("dynastorev2: conditions can't refer to payload fields when encryption or blob offload is enabled")
+
+	// ErrBatchWriteUnprocessed batch write failed as DynamoDB didn't process all the items after retrying
+	ErrBatchWriteUnprocessed = errors.New("dynastorev2: batch write failed as items remained unprocessed after retrying")
+
+	// ErrRestoreFailedKeyNotDeleted restore failed as the partition and sort keys didn't exist as a soft deleted record in the table
+	ErrRestoreFailedKeyNotDeleted = errors.New("dynastorev2: restore failed as the partition and sort keys didn't exist as a deleted record in the table")
 )
 
 // Key ensures the partition or sort key used is a valid type for DynamoDB, note this is also
@@ -69,11 +117,18 @@ func New[P Key, S Key, V any](client *dynamodb.Client, tableName string, options
 		client:    client,
 		tableName: tableName,
 		fields: fieldsDef{
-			partitionKeyName: DefaultPartitionKeyAttribute,
-			sortKeyName:      DefaultSortKeyAttribute,
-			expiresName:      DefaultExpiresAttribute,
-			versionName:      DefaultVersionAttribute,
-			payloadName:      DefaultPayloadAttribute,
+			partitionKeyName:   DefaultPartitionKeyAttribute,
+			sortKeyName:        DefaultSortKeyAttribute,
+			expiresName:        DefaultExpiresAttribute,
+			versionName:        DefaultVersionAttribute,
+			payloadName:        DefaultPayloadAttribute,
+			keyIDName:          DefaultKeyIDAttribute,
+			dataKeyName:        DefaultDataKeyAttribute,
+			blobKeyName:        DefaultBlobKeyAttribute,
+			blobChecksumName:   DefaultBlobChecksumAttribute,
+			schemaVersionName:  DefaultSchemaVersionAttribute,
+			deletedAtName:      DefaultDeletedAtAttribute,
+			deletedExpiresName: DefaultDeletedExpiresAttribute,
 		},
 		storeOptions: &StoreOptions[P, S, V]{
 			storeHooks: &StoreHooks[P, S, V]{
@@ -84,6 +139,7 @@ func New[P Key, S Key, V any](client *dynamodb.Client, tableName string, options
 					return ctx
 				},
 			},
+			consumedCapacity: types.ReturnConsumedCapacityTotal,
 		},
 	}
 
@@ -94,11 +150,18 @@ func New[P Key, S Key, V any](client *dynamodb.Client, tableName string, options
 
 // fieldsDef names of the core fields used to manage data in this table
 type fieldsDef struct {
-	partitionKeyName string
-	sortKeyName      string
-	expiresName      string
-	versionName      string
-	payloadName      string
+	partitionKeyName   string
+	sortKeyName        string
+	expiresName        string
+	versionName        string
+	payloadName        string
+	keyIDName          string
+	dataKeyName        string
+	blobKeyName        string
+	blobChecksumName   string
+	schemaVersionName  string
+	deletedAtName      string
+	deletedExpiresName string
 }
 
 // Create a record in DynamoDB using the provided partition and sort keys, a payload containing the value
@@ -111,123 +174,174 @@ func (t *Store[P, S, V]) Create(ctx context.Context, partitionKey P, sortKey S,
 	defaultOpts := t.defaultWriteOptions()
 	ApplyWriteOptions(defaultOpts, options...)
 
-	update, err := t.buildUpdate(value, defaultOpts)
-	if err != nil {
-		return nil, fmt.Errorf("dynastorev2: failed to build update: %w", err)
+	if err := t.checkCondition(defaultOpts.condition); err != nil {
+		return nil, err
 	}
 
-	builder := dexp.NewBuilder().WithUpdate(update)
+	var createCondition dexp.ConditionBuilder
 
 	if !defaultOpts.createConstraintDisabled {
-		// assign a condition which requires the record to existing before being updated
-		createCondition := dexp.AttributeNotExists(dexp.Name(t.fields.partitionKeyName)).And(dexp.AttributeNotExists(dexp.Name(t.fields.sortKeyName)))
-
-		builder = builder.WithCondition(createCondition)
+		// assign a condition which requires the record to not exist, or be soft deleted, before being created
+		createCondition = t.notExistsCondition()
 	}
 
 	// TODO Add an exclusion for expired records which haven't been cleaned up yet
 
-	expr, err := builder.Build()
-	if err != nil {
-		return nil, fmt.Errorf("dynastorev2: failed to build update expression: %w", err)
+	createCondition = andConditions(createCondition, defaultOpts.condition)
+
+	if t.storeOptions.softDeleteRetention == 0 {
+		return t.writePayload(ctx, partitionKey, sortKey, value, defaultOpts, createCondition)
 	}
 
-	result, err := t.doUpdate(ctx, partitionKey, sortKey, value, expr)
-	if err != nil {
-		return nil, err
+	// a record created in place of a soft deleted record replaces the whole item, so it doesn't inherit the TTL,
+	// extra fields, counters or version of the record which was deleted
+	if !defaultOpts.createConstraintDisabled {
+		return t.putPayload(ctx, partitionKey, sortKey, value, defaultOpts, createCondition)
 	}
 
-	var version int64
-	if attr, ok := result.Attributes[t.fields.versionName]; ok {
-		err := attributevalue.Unmarshal(attr, &version)
-		if err != nil {
-			return nil, fmt.Errorf("failed to extract version attribute: %w", err)
-		}
+	// without the constraint a live record is updated in place as it would be without soft delete, so only a
+	// tombstone is replaced. Each attempt is conditional on the tombstone so only one can succeed.
+	deletedAt := dexp.Name(t.fields.deletedAtName)
+
+	res, err := t.writePayload(ctx, partitionKey, sortKey, value, defaultOpts, andConditions(dexp.AttributeNotExists(deletedAt), defaultOpts.condition))
+
+	var oe *types.ConditionalCheckFailedException
+	if !errors.As(err, &oe) {
+		return res, err
 	}
 
-	return &OperationResult{
-		Version:          version,
-		ConsumedCapacity: result.ConsumedCapacity,
-	}, nil
+	return t.putPayload(ctx, partitionKey, sortKey, value, defaultOpts, andConditions(dexp.AttributeExists(deletedAt), defaultOpts.condition))
 }
 
 // Get a record in DynamoDB using the provided partition and sort keys
 func (t *Store[P, S, V]) Get(ctx context.Context, partitionKey P, sortKey S, options ...ReadOption[P, S]) (*OperationResult, V, error) {
+	ctx = setOperationDetails(ctx, "Get", partitionKey, sortKey)
 
-	var val V
+	res, record, err := t.getRecord(ctx, partitionKey, sortKey, options...)
 
-	ctx = setOperationDetails(ctx, "Get", partitionKey, sortKey)
+	return res, record.Value, err
+}
+
+// GetRecord a record in DynamoDB using the provided partition and sort keys, returning the keys, expiry and extra fields
+// stored alongside the value
+func (t *Store[P, S, V]) GetRecord(ctx context.Context, partitionKey P, sortKey S, options ...ReadOption[P, S]) (*OperationResult, Record[P, S, V], error) {
+	ctx = setOperationDetails(ctx, "GetRecord", partitionKey, sortKey)
+
+	return t.getRecord(ctx, partitionKey, sortKey, options...)
+}
+
+// ListBySortKeyPrefix perform a query of the DynamoDB using hte partition key and a string prefix
+// for the sort key. This is typically used when hierarchies are stored in this partition. For example
+// if we have a customer addresses with an sort key with a format of (customer id)/(address id),
+// to list the addresses for a customer you list using the customer id as the prefix.
+//
+// Notes:
+// 1. You the sort key must be a string to support this operation, this is a limitation of the AWs SDK.
+// 2. ListBySortKeyPrefix will also return expired records as these may hang around for up to 48 hours according to the documentation, see: https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/howitworks-ttl.html
+func (t *Store[P, S, V]) ListBySortKeyPrefix(ctx context.Context, partitionKey P, prefix string, options ...ReadOption[P, S]) (*OperationResult, []V, error) {
+	var vals []V
+
+	ctx = setOperationDetails(ctx, "ListBySortKeyPrefix", partitionKey, prefix)
+
+	res, records, err := t.listRecords(ctx, partitionKey, prefix, options...)
+	if err != nil {
+		return nil, vals, err
+	}
+
+	for _, record := range records {
+		vals = append(vals, record.Value)
+	}
+
+	return res, vals, nil
+}
+
+// ListRecordsBySortKeyPrefix perform a query of the DynamoDB using the partition key and a string prefix for the sort
+// key, returning the keys, version, expiry and extra fields stored alongside each value.
+//
+// See ListBySortKeyPrefix for more details.
+func (t *Store[P, S, V]) ListRecordsBySortKeyPrefix(ctx context.Context, partitionKey P, prefix string, options ...ReadOption[P, S]) (*OperationResult, []Record[P, S, V], error) {
+	ctx = setOperationDetails(ctx, "ListRecordsBySortKeyPrefix", partitionKey, prefix)
+
+	return t.listRecords(ctx, partitionKey, prefix, options...)
+}
+
+// getRecord reads the item and decodes it into a record
+func (t *Store[P, S, V]) getRecord(ctx context.Context, partitionKey P, sortKey S, options ...ReadOption[P, S]) (*OperationResult, Record[P, S, V], error) {
+	var record Record[P, S, V]
 
 	defaultOpts := t.defaultReadOptions()
 	ApplyReadOptions(defaultOpts, options...)
 
 	key, err := t.buildKey(partitionKey, sortKey)
 	if err != nil {
-		return nil, val, err
+		return nil, record, err
 	}
 
 	// TODO Add an exclusion for expired records which haven't been cleaned up yet
 
+	useCache := t.storeOptions.cache != nil && !defaultOpts.consistentRead && !defaultOpts.includeDeleted
+
+	if useCache {
+		if record, ok := t.getCachedRecord(ctx, partitionKey, sortKey); ok {
+			return &OperationResult{Version: record.Version}, record, nil
+		}
+	}
+
 	getItem := &dynamodb.GetItemInput{
 		TableName:              aws.String(t.tableName),
 		Key:                    key,
-		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+		ReturnConsumedCapacity: t.storeOptions.consumedCapacity,
 		ConsistentRead:         aws.Bool(defaultOpts.consistentRead),
 	}
 
-	ctx = t.storeOptions.storeHooks.RequestBuilt(ctx, partitionKey, sortKey, getItem)
-
-	readResp, err := t.client.GetItem(ctx, getItem)
+	readResp, err := send(ctx, t, partitionKey, sortKey, getItem, t.client.GetItem)
 	if err != nil {
-		return nil, val, fmt.Errorf("dynastorev2: failed to get record: %w", err)
+		return nil, record, fmt.Errorf("dynastorev2: failed to get record: %w", err)
 	}
 
-	t.storeOptions.storeHooks.ResponseReceived(ctx, partitionKey, sortKey, readResp.ConsumedCapacity)
-
 	if readResp.Item == nil {
-		return nil, val, ErrKeyNotExists
+		return nil, record, ErrKeyNotExists
 	}
 
-	if attr, ok := readResp.Item[t.fields.payloadName]; ok {
-		err = attributevalue.Unmarshal(attr, &val)
-		if err != nil {
-			return nil, val, fmt.Errorf("dynastorev2: failed to unmarshal payload attribute: %w", err)
-		}
+	if t.isDeleted(readResp.Item) && !defaultOpts.includeDeleted {
+		return nil, record, ErrKeyNotExists
 	}
 
-	var version int64
-	if attr, ok := readResp.Item[t.fields.versionName]; ok {
-		err := attributevalue.Unmarshal(attr, &version)
-		if err != nil {
-			return nil, val, fmt.Errorf("dynastorev2: failed to extract version attribute: %w", err)
+	record, upgraded, err := t.decodeRecord(ctx, readResp.Item)
+	if err != nil {
+		return nil, record, err
+	}
+
+	writtenBack := false
+
+	if upgraded && t.storeOptions.schemaWriteBack {
+		// the write back is best effort, if it fails the upgrade is applied again on the next read
+		if newVersion, err := t.writeBackUpgrade(ctx, readResp.Item, record.Value, record.Version); err == nil {
+			record.Version, writtenBack = newVersion, true
 		}
 	}
 
+	// the item which was read is stale once the upgrade is written back
+	if useCache && !writtenBack {
+		t.setCachedItem(ctx, partitionKey, sortKey, readResp.Item, &record.Value, false)
+	}
+
 	return &OperationResult{
-		Version:          version,
+		Version:          record.Version,
 		ConsumedCapacity: readResp.ConsumedCapacity,
-	}, val, nil
+	}, record, nil
 }
 
-// ListBySortKeyPrefix perform a query of the DynamoDB using hte partition key and a string prefix
-// for the sort key. This is typically used when hierarchies are stored in this partition. For example
-// if we have a customer addresses with an sort key with a format of (customer id)/(address id),
-// to list the addresses for a customer you list using the customer id as the prefix.
-//
-// Notes:
-// 1. You the sort key must be a string to support this operation, this is a limitation of the AWs SDK.
-// 2. ListBySortKeyPrefix will also return expired records as these may hang around for up to 48 hours according to the documentation, see: https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/howitworks-ttl.html
-func (t *Store[P, S, V]) ListBySortKeyPrefix(ctx context.Context, partitionKey P, prefix string, options ...ReadOption[P, S]) (*OperationResult, []V, error) {
-	var vals []V
-
-	ctx = setOperationDetails(ctx, "ListBySortKeyPrefix", partitionKey, prefix)
+// listRecords queries the partition for items with a sort key starting with the prefix and decodes them into records
+func (t *Store[P, S, V]) listRecords(ctx context.Context, partitionKey P, prefix string, options ...ReadOption[P, S]) (*OperationResult, []Record[P, S, V], error) {
+	var records []Record[P, S, V]
 
 	defaultOpts := t.defaultReadOptions()
 	ApplyReadOptions(defaultOpts, options...)
 
 	pk, err := attributevalue.Marshal(partitionKey)
 	if err != nil {
-		return nil, vals, fmt.Errorf("dynastorev2: failed to build partition key: %w", err)
+		return nil, records, fmt.Errorf("dynastorev2: failed to build partition key: %w", err)
 	}
 
 	partitionKeyName := t.fields.partitionKeyName
@@ -240,15 +354,23 @@ func (t *Store[P, S, V]) ListBySortKeyPrefix(ctx context.Context, partitionKey P
 
 	keyCond := dexp.KeyEqual(dexp.Key(partitionKeyName), dexp.Value(pk)).And(dexp.KeyBeginsWith(dexp.Key(sortKeyName), prefix))
 
-	expr, err := dexp.NewBuilder().WithKeyCondition(keyCond).Build()
+	builder := dexp.NewBuilder().WithKeyCondition(keyCond)
+
+	// note records excluded by the filter still count towards the limit
+	if filter := t.readFilter(defaultOpts); filter.IsSet() {
+		builder = builder.WithFilter(filter)
+	}
+
+	expr, err := builder.Build()
 	if err != nil {
-		return nil, vals, fmt.Errorf("dynastorev2: failed to build list expression: %w", err)
+		return nil, records, fmt.Errorf("dynastorev2: failed to build list expression: %w", err)
 	}
 
 	queryInput := &dynamodb.QueryInput{
 		TableName:                 aws.String(t.tableName),
-		ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+		ReturnConsumedCapacity:    t.storeOptions.consumedCapacity,
 		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
 		ScanIndexForward:          aws.Bool(!defaultOpts.reverseSortResults), // the API is backwards IMO
@@ -261,7 +383,7 @@ func (t *Store[P, S, V]) ListBySortKeyPrefix(ctx context.Context, partitionKey P
 	if defaultOpts.lastEvaluatedKey != "" {
 		err = parseLastEvaluatedKey(defaultOpts.lastEvaluatedKey, queryInput)
 		if err != nil {
-			return nil, vals, err
+			return nil, records, err
 		}
 	}
 
@@ -269,30 +391,38 @@ func (t *Store[P, S, V]) ListBySortKeyPrefix(ctx context.Context, partitionKey P
 		queryInput.Limit = aws.Int32(defaultOpts.limit)
 	}
 
-	res, err := t.client.Query(ctx, queryInput)
+	var sortKey S
+
+	res, err := send(ctx, t, partitionKey, sortKey, queryInput, t.client.Query)
 	if err != nil {
-		return nil, vals, fmt.Errorf("dynastorev2: failed to execute query: %w", err)
+		return nil, records, fmt.Errorf("dynastorev2: failed to execute query: %w", err)
 	}
 
 	for _, item := range res.Items {
-		var val V
-		err = attributevalue.Unmarshal(item[t.fields.payloadName], &val)
+		record, upgraded, err := t.decodeRecord(ctx, item)
 		if err != nil {
-			return nil, vals, fmt.Errorf("dynastorev2: failed to unmarshal item: %w", err)
+			return nil, records, err
+		}
+
+		if upgraded && t.storeOptions.schemaWriteBack {
+			// the write back is best effort, if it fails the upgrade is applied again on the next read
+			if newVersion, err := t.writeBackUpgrade(ctx, item, record.Value, record.Version); err == nil {
+				record.Version = newVersion
+			}
 		}
 
-		vals = append(vals, val)
+		records = append(records, record)
 	}
 
 	lastEvaluatedKey, err := encodeLastEvaluatedKey(res)
 	if err != nil {
-		return nil, vals, err
+		return nil, records, err
 	}
 
 	return &OperationResult{
 		ConsumedCapacity: res.ConsumedCapacity,
 		LastEvaluatedKey: lastEvaluatedKey,
-	}, vals, nil
+	}, records, nil
 }
 
 // Update a record in DynamoDB using the provided partition and sort keys, a payload containing the value
@@ -305,40 +435,20 @@ func (t *Store[P, S, V]) Update(ctx context.Context, partitionKey P, sortKey S,
 	defaultOpts := t.defaultWriteOptions()
 	ApplyWriteOptions(defaultOpts, options...)
 
-	update, err := t.buildUpdate(value, defaultOpts)
-	if err != nil {
-		return nil, fmt.Errorf("dynastorev2: failed to build update: %w", err)
+	if err := t.checkCondition(defaultOpts.condition); err != nil {
+		return nil, err
 	}
 
 	// assign a condition which requires the record to existing before being updated
-	updateCondition := dexp.AttributeExists(dexp.Name(t.fields.partitionKeyName)).And(dexp.AttributeExists(dexp.Name(t.fields.sortKeyName)))
+	updateCondition := t.existsCondition()
 
 	if defaultOpts.version > 0 {
 		updateCondition = updateCondition.And(dexp.Equal(dexp.Name(t.fields.versionName), dexp.Value(defaultOpts.version)))
 	}
 
-	expr, err := dexp.NewBuilder().WithUpdate(update).WithCondition(updateCondition).Build()
-	if err != nil {
-		return nil, fmt.Errorf("dynastorev2: failed to build update expression: %w", err)
-	}
-
-	result, err := t.doUpdate(ctx, partitionKey, sortKey, value, expr)
-	if err != nil {
-		return nil, err
-	}
-
-	var version int64
-	if attr, ok := result.Attributes[t.fields.versionName]; ok {
-		err := attributevalue.Unmarshal(attr, &version)
-		if err != nil {
-			return nil, fmt.Errorf("dynastorev2: failed to extract version attribute: %w", err)
-		}
-	}
+	updateCondition = andConditions(updateCondition, defaultOpts.condition)
 
-	return &OperationResult{
-		ConsumedCapacity: result.ConsumedCapacity,
-		Version:          version,
-	}, nil
+	return t.writePayload(ctx, partitionKey, sortKey, value, defaultOpts, updateCondition)
 }
 
 // Delete a record in DynamoDB using the provided partition and sort keys
@@ -348,48 +458,97 @@ func (t *Store[P, S, V]) Delete(ctx context.Context, partitionKey P, sortKey S,
 	defaultOpts := t.defaultDeleteOptions()
 	ApplyDeleteOptions(defaultOpts, options...)
 
-	builder := dexp.NewBuilder()
-
-	// if the delete check is enabled we add a dynamodb attribute exists condition for the partition and sort keys
-	if defaultOpts.existsCheck {
-		deleteCondition := dexp.AttributeExists(dexp.Name(t.fields.partitionKeyName)).And(dexp.AttributeExists(dexp.Name(t.fields.sortKeyName)))
-		builder = builder.WithCondition(deleteCondition)
+	if err := t.checkCondition(defaultOpts.condition); err != nil {
+		return err
 	}
 
-	expr, err := builder.Build()
-	if err != nil {
-		return fmt.Errorf("dynastorev2: failed to build update expression: %w", err)
+	if t.storeOptions.softDeleteRetention > 0 {
+		// the old item provides the version recorded in the cache so older versions aren't cached after the delete
+		returnValues := types.ReturnValueNone
+		if t.storeOptions.cache != nil {
+			returnValues = types.ReturnValueAllOld
+		}
+
+		_, err := t.softDelete(ctx, partitionKey, sortKey, defaultOpts, returnValues)
+		return err
 	}
 
-	key, err := t.buildKey(partitionKey, sortKey)
+	// the old item is needed to locate any offloaded payload so it can be cleaned up
+	deteteResp, err := t.doDelete(ctx, partitionKey, sortKey, defaultOpts, t.storeOptions.blobStore != nil)
 	if err != nil {
 		return err
 	}
 
-	deleteItem := &dynamodb.DeleteItemInput{
-		TableName:                 aws.String(t.tableName),
-		Key:                       key,
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		ConditionExpression:       expr.Condition(),
-		ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+	return t.deleteItemBlob(ctx, deteteResp.Attributes)
+}
+
+// DeleteAndReturn a record in DynamoDB using the provided partition and sort keys, returning the value and version
+// of the record which was deleted. This avoids a read prior to the delete when moving or popping records.
+func (t *Store[P, S, V]) DeleteAndReturn(ctx context.Context, partitionKey P, sortKey S, options ...DeleteOption[P, S]) (*OperationResult, V, error) {
+	var val V
+
+	ctx = setOperationDetails(ctx, "DeleteAndReturn", partitionKey, sortKey)
+
+	defaultOpts := t.defaultDeleteOptions()
+	ApplyDeleteOptions(defaultOpts, options...)
+
+	if err := t.checkCondition(defaultOpts.condition); err != nil {
+		return nil, val, err
 	}
 
-	ctx = t.storeOptions.storeHooks.RequestBuilt(ctx, partitionKey, sortKey, deleteItem)
+	var (
+		attributes       map[string]types.AttributeValue
+		consumedCapacity *types.ConsumedCapacity
+	)
 
-	deteteResp, err := t.client.DeleteItem(ctx, deleteItem)
-	if err != nil {
-		var oe *types.ConditionalCheckFailedException
-		if errors.As(err, &oe) {
-			return ErrDeleteFailedKeyNotExists
+	if t.storeOptions.softDeleteRetention > 0 {
+		updateResp, err := t.softDelete(ctx, partitionKey, sortKey, defaultOpts, types.ReturnValueAllOld)
+		if err != nil {
+			return nil, val, err
+		}
+
+		attributes, consumedCapacity = updateResp.Attributes, updateResp.ConsumedCapacity
+	} else {
+		deteteResp, err := t.doDelete(ctx, partitionKey, sortKey, defaultOpts, true)
+		if err != nil {
+			return nil, val, err
 		}
 
-		return fmt.Errorf("dynastorev2: failed to delete record: %w", err)
+		attributes, consumedCapacity = deteteResp.Attributes, deteteResp.ConsumedCapacity
 	}
 
-	t.storeOptions.storeHooks.ResponseReceived(ctx, partitionKey, sortKey, deteteResp.ConsumedCapacity)
+	// the exists check is disabled and there was no record to delete
+	if len(attributes) == 0 || t.isDeleted(attributes) {
+		return nil, val, ErrDeleteFailedKeyNotExists
+	}
 
-	return nil
+	val, _, decodeErr := t.decodePayload(ctx, attributes)
+
+	// the record is deleted so the blob is cleaned up even if the payload couldn't be decoded, soft deleted records
+	// retain the blob so they can be restored
+	if t.storeOptions.softDeleteRetention == 0 {
+		err := t.deleteItemBlob(ctx, attributes)
+		if err != nil {
+			return nil, val, err
+		}
+	}
+
+	if decodeErr != nil {
+		return nil, val, decodeErr
+	}
+
+	var version int64
+	if attr, ok := attributes[t.fields.versionName]; ok {
+		err := attributevalue.Unmarshal(attr, &version)
+		if err != nil {
+			return nil, val, fmt.Errorf("dynastorev2: failed to extract version attribute: %w", err)
+		}
+	}
+
+	return &OperationResult{
+		Version:          version,
+		ConsumedCapacity: consumedCapacity,
+	}, val, nil
 }
 
 // WriteWithTTL assigns a time to live (TTL) to the record when it is created or updated
@@ -398,6 +557,11 @@ func (t *Store[P, S, V]) WriteWithTTL(ttl time.Duration) WriteOption[P, S, V] {
 
 }
 
+// WriteWithTTLCleared removes the time to live (TTL) from the record when it is updated so it never expires
+func (t *Store[P, S, V]) WriteWithTTLCleared() WriteOption[P, S, V] {
+	return writeWithTTLCleared[P, S, V]()
+}
+
 // WriteWithVersion adds a condition check the provided version to enable optimistic locking
 func (t *Store[P, S, V]) WriteWithVersion(version int64) WriteOption[P, S, V] {
 	return writeWithVersion[P, S, V](version)
@@ -408,11 +572,30 @@ func (t *Store[P, S, V]) WriteWithExtraFields(extraFields map[string]any) WriteO
 	return writeWithExtraFields[P, S, V](extraFields)
 }
 
+// WriteWithRemoveFields removes the named extra fields from the record when it is updated
+func (t *Store[P, S, V]) WriteWithRemoveFields(names ...string) WriteOption[P, S, V] {
+	return writeWithRemoveFields[P, S, V](names)
+}
+
+// WriteWithCondition adds a condition which must be met for the write to succeed, this is combined with the
+// conditions added by the store using AND. Use PayloadField to refer to fields within the payload, this isn't
+// supported when encryption or blob offload is enabled and ErrPayloadConditionUnsupported is returned.
+//
+// If the condition isn't met the error returned wraps types.ConditionalCheckFailedException.
+func (t *Store[P, S, V]) WriteWithCondition(condition dexp.ConditionBuilder) WriteOption[P, S, V] {
+	return writeWithCondition[P, S, V](condition)
+}
+
 // WriteWithCreateConstraintDisabled disable the check on create for existence of the rows
 func (t *Store[P, S, V]) WriteWithCreateConstraintDisabled(createConstraintDisabled bool) WriteOption[P, S, V] {
 	return writeWithCreateConstraintDisabled[P, S, V](createConstraintDisabled)
 }
 
+// WriteWithCreateIfMissing create the record if it doesn't exist when incrementing counters
+func (t *Store[P, S, V]) WriteWithCreateIfMissing(createIfMissing bool) WriteOption[P, S, V] {
+	return writeWithCreateIfMissing[P, S, V](createIfMissing)
+}
+
 // ReadWithConsistentRead enable the consistent read flag when performing get operations
 func (t *Store[P, S, V]) ReadWithConsistentRead(consistentRead bool) ReadOption[P, S] {
 	return readWithConsistentRead[P, S](consistentRead)
@@ -438,12 +621,184 @@ func (t *Store[P, S, V]) ReadWithIndex(name, partKey, sortKey string) ReadOption
 	return readWithIndex[P, S](name, partKey, sortKey)
 }
 
+// ReadWithFilter adds a filter which records must match when performing list and scan operations, multiple filters are
+// combined using AND. Use PayloadField to refer to fields within the payload.
+func (t *Store[P, S, V]) ReadWithFilter(filter dexp.ConditionBuilder) ReadOption[P, S] {
+	return readWithFilter[P, S](filter)
+}
+
+// ReadWithDeleted include soft deleted records when performing get and list operations
+func (t *Store[P, S, V]) ReadWithDeleted(includeDeleted bool) ReadOption[P, S] {
+	return readWithDeleted[P, S](includeDeleted)
+}
+
 // DeleteWithCheck delete with a check condition to ensure the record exists
 func (t *Store[P, S, V]) DeleteWithCheck(enabled bool) DeleteOption[P, S] {
 	return deleteWithCheck[P, S](enabled)
 }
 
-func (t *Store[P, S, V]) doUpdate(ctx context.Context, partitionKey P, sortKey S, value V, expr dexp.Expression) (*dynamodb.UpdateItemOutput, error) {
+// DeleteWithVersion adds a condition check the provided version to enable optimistic locking
+//
+// If the record exists but the version doesn't match ErrDeleteFailedConditionCheck is returned.
+func (t *Store[P, S, V]) DeleteWithVersion(version int64) DeleteOption[P, S] {
+	return deleteWithVersion[P, S](version)
+}
+
+// DeleteWithCondition adds a condition which must be met for the delete to succeed, this is combined with the
+// exists check using AND. Use PayloadField to refer to fields within the payload, this isn't supported when encryption
+// or blob offload is enabled and ErrPayloadConditionUnsupported is returned.
+//
+// If the record exists but the condition isn't met ErrDeleteFailedConditionCheck is returned.
+func (t *Store[P, S, V]) DeleteWithCondition(condition dexp.ConditionBuilder) DeleteOption[P, S] {
+	return deleteWithCondition[P, S](condition)
+}
+
+// writePayload validates and encodes the value then writes it to the item along with the version and any extra fields,
+// the write is conditional on the provided condition if it is set.
+func (t *Store[P, S, V]) writePayload(ctx context.Context, partitionKey P, sortKey S, value V, options *WriteOptions[P, S, V], condition dexp.ConditionBuilder) (*OperationResult, error) {
+	err := t.validate(value)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := t.buildKey(partitionKey, sortKey)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := t.encodePayload(ctx, key, value)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to build update: %w", err)
+	}
+
+	update, err := t.buildUpdate(payload, options)
+	if err != nil {
+		t.discardBlob(ctx, payload.blobKey)
+		return nil, fmt.Errorf("dynastorev2: failed to build update: %w", err)
+	}
+
+	builder := dexp.NewBuilder().WithUpdate(update)
+
+	if condition.IsSet() {
+		builder = builder.WithCondition(condition)
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		t.discardBlob(ctx, payload.blobKey)
+		return nil, fmt.Errorf("dynastorev2: failed to build update expression: %w", err)
+	}
+
+	// when blobs are in use the old item is returned so the blob it references can be cleaned up
+	returnValues := types.ReturnValueAllNew
+	if t.storeOptions.blobStore != nil {
+		returnValues = types.ReturnValueAllOld
+	}
+
+	result, err := t.doUpdate(ctx, partitionKey, sortKey, expr, returnValues, &value)
+	if err != nil {
+		t.discardBlob(ctx, payload.blobKey)
+		return nil, err
+	}
+
+	var version int64
+	if attr, ok := result.Attributes[t.fields.versionName]; ok {
+		err := attributevalue.Unmarshal(attr, &version)
+		if err != nil {
+			return nil, fmt.Errorf("dynastorev2: failed to extract version attribute: %w", err)
+		}
+	}
+
+	if returnValues == types.ReturnValueAllOld {
+		// the version is incremented by one from the old value, or starts at one for new records
+		version++
+
+		if oldBlobKey := t.blobKeyFromItem(result.Attributes); oldBlobKey != payload.blobKey {
+			t.discardBlob(ctx, oldBlobKey)
+		}
+	}
+
+	return &OperationResult{
+		Version:          version,
+		ConsumedCapacity: result.ConsumedCapacity,
+	}, nil
+}
+
+// putPayload validates and encodes the value then writes a new item containing it along with the extra fields and
+// TTL, replacing any existing item. The write is conditional on the provided condition.
+func (t *Store[P, S, V]) putPayload(ctx context.Context, partitionKey P, sortKey S, value V, options *WriteOptions[P, S, V], condition dexp.ConditionBuilder) (*OperationResult, error) {
+	record := Record[P, S, V]{
+		PartitionKey: partitionKey,
+		SortKey:      sortKey,
+		Version:      1,
+		Fields:       options.extraFields,
+		Value:        value,
+	}
+
+	if options.ttl > 0 {
+		expires := time.Now().Add(options.ttl)
+		record.Expires = &expires
+	}
+
+	item, err := t.buildRecordItem(ctx, record)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to build item: %w", err)
+	}
+
+	blobKey := t.blobKeyFromItem(item)
+
+	expr, err := dexp.NewBuilder().WithCondition(condition).Build()
+	if err != nil {
+		t.discardBlob(ctx, blobKey)
+		return nil, fmt.Errorf("dynastorev2: failed to build condition expression: %w", err)
+	}
+
+	putItem := &dynamodb.PutItemInput{
+		TableName:                 aws.String(t.tableName),
+		Item:                      item,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
+		ReturnConsumedCapacity:    t.storeOptions.consumedCapacity,
+	}
+
+	// when blobs are in use the replaced item is returned so the blob it references can be cleaned up
+	if t.storeOptions.blobStore != nil {
+		putItem.ReturnValues = types.ReturnValueAllOld
+	}
+
+	putResp, err := send(ctx, t, partitionKey, sortKey, putItem, t.client.PutItem)
+	if err != nil {
+		t.invalidateCachedRecord(ctx, partitionKey, sortKey)
+		t.discardBlob(ctx, blobKey)
+		return nil, fmt.Errorf("dynastorev2: failed to put item: %w", err)
+	}
+
+	t.setCachedItem(ctx, partitionKey, sortKey, item, &value, true)
+
+	if oldBlobKey := t.blobKeyFromItem(putResp.Attributes); oldBlobKey != blobKey {
+		t.discardBlob(ctx, oldBlobKey)
+	}
+
+	return &OperationResult{
+		Version:          record.Version,
+		ConsumedCapacity: putResp.ConsumedCapacity,
+	}, nil
+}
+
+// discardBlob removes a blob which is no longer referenced by an item, this is best effort as the blob is orphaned
+// and won't be read again
+func (t *Store[P, S, V]) discardBlob(ctx context.Context, blobKey string) {
+	if blobKey == "" {
+		return
+	}
+
+	_ = t.storeOptions.blobStore.DeleteBlob(ctx, blobKey)
+}
+
+// doUpdate applies the update expression to the item, the value is provided if it was written by the update so it
+// can be cached along with the new item
+func (t *Store[P, S, V]) doUpdate(ctx context.Context, partitionKey P, sortKey S, expr dexp.Expression, returnValues types.ReturnValue, value *V) (*dynamodb.UpdateItemOutput, error) {
 	key, err := t.buildKey(partitionKey, sortKey)
 	if err != nil {
 		return nil, err
@@ -456,22 +811,121 @@ func (t *Store[P, S, V]) doUpdate(ctx context.Context, partitionKey P, sortKey S
 		ExpressionAttributeValues: expr.Values(),
 		UpdateExpression:          expr.Update(),
 		ConditionExpression:       expr.Condition(),
-		ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
-		ReturnValues:              types.ReturnValueAllNew,
+		ReturnConsumedCapacity:    t.storeOptions.consumedCapacity,
+		ReturnValues:              returnValues,
 	}
 
-	ctx = t.storeOptions.storeHooks.RequestBuilt(ctx, partitionKey, sortKey, updateItem)
-
-	updateResp, err := t.client.UpdateItem(ctx, updateItem)
+	updateResp, err := send(ctx, t, partitionKey, sortKey, updateItem, t.client.UpdateItem)
 	if err != nil {
+		// the cached record is removed as a failed condition may indicate it is stale
+		t.invalidateCachedRecord(ctx, partitionKey, sortKey)
 		return nil, fmt.Errorf("dynastorev2: failed to update item: %w", err)
 	}
 
-	t.storeOptions.storeHooks.ResponseReceived(ctx, partitionKey, sortKey, updateResp.ConsumedCapacity)
+	switch returnValues {
+	case types.ReturnValueAllNew:
+		t.setCachedItem(ctx, partitionKey, sortKey, updateResp.Attributes, value, true)
+	case types.ReturnValueAllOld:
+		// the version is incremented by one from the old value, or starts at one for new records
+		t.supersedeCachedRecord(ctx, partitionKey, sortKey, t.itemVersion(updateResp.Attributes)+1)
+	default:
+		// counter updates don't modify the version so there is no version to supersede the entry with
+		t.invalidateCachedRecord(ctx, partitionKey, sortKey)
+	}
 
 	return updateResp, nil
 }
 
+func (t *Store[P, S, V]) doDelete(ctx context.Context, partitionKey P, sortKey S, options *DeleteOptions[P, S], returnOld bool) (*dynamodb.DeleteItemOutput, error) {
+	builder := dexp.NewBuilder()
+
+	var deleteCondition dexp.ConditionBuilder
+
+	// if the delete check is enabled we add a dynamodb attribute exists condition for the partition and sort keys
+	if options.existsCheck {
+		deleteCondition = t.existsCondition()
+	}
+
+	if options.version > 0 {
+		deleteCondition = andConditions(deleteCondition, dexp.Equal(dexp.Name(t.fields.versionName), dexp.Value(options.version)))
+	}
+
+	deleteCondition = andConditions(deleteCondition, options.condition)
+
+	if deleteCondition.IsSet() {
+		builder = builder.WithCondition(deleteCondition)
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to build update expression: %w", err)
+	}
+
+	key, err := t.buildKey(partitionKey, sortKey)
+	if err != nil {
+		return nil, err
+	}
+
+	deleteItem := &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(t.tableName),
+		Key:                       key,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
+		ReturnConsumedCapacity:    t.storeOptions.consumedCapacity,
+	}
+
+	// the old item also provides the version recorded in the cache so older versions aren't cached after the delete
+	if returnOld || t.storeOptions.cache != nil {
+		deleteItem.ReturnValues = types.ReturnValueAllOld
+	}
+
+	// the existing item is returned when the condition fails to determine whether the record was missing or the
+	// provided version or condition wasn't met
+	if options.version > 0 || options.condition.IsSet() {
+		deleteItem.ReturnValuesOnConditionCheckFailure = types.ReturnValuesOnConditionCheckFailureAllOld
+	}
+
+	deteteResp, err := send(ctx, t, partitionKey, sortKey, deleteItem, t.client.DeleteItem)
+	if err != nil {
+		t.invalidateCachedRecord(ctx, partitionKey, sortKey)
+
+		var oe *types.ConditionalCheckFailedException
+		if errors.As(err, &oe) {
+			if len(oe.Item) > 0 {
+				return nil, ErrDeleteFailedConditionCheck
+			}
+
+			return nil, ErrDeleteFailedKeyNotExists
+		}
+
+		return nil, fmt.Errorf("dynastorev2: failed to delete record: %w", err)
+	}
+
+	if len(deteteResp.Attributes) > 0 {
+		t.supersedeCachedRecord(ctx, partitionKey, sortKey, t.itemVersion(deteteResp.Attributes)+1)
+	} else {
+		t.invalidateCachedRecord(ctx, partitionKey, sortKey)
+	}
+
+	return deteteResp, nil
+}
+
+// deleteItemBlob removes the blob referenced by a deleted item, if the payload was offloaded
+func (t *Store[P, S, V]) deleteItemBlob(ctx context.Context, item map[string]types.AttributeValue) error {
+	blobKey := t.blobKeyFromItem(item)
+	if blobKey == "" || t.storeOptions.blobStore == nil {
+		return nil
+	}
+
+	err := t.storeOptions.blobStore.DeleteBlob(ctx, blobKey)
+	if err != nil {
+		return fmt.Errorf("dynastorev2: record deleted but failed to delete blob: %w", err)
+	}
+
+	return nil
+}
+
 func (t *Store[P, S, V]) buildKey(partitionKey P, sortKey S) (map[string]types.AttributeValue, error) {
 
 	pk, err := attributevalue.Marshal(partitionKey)
@@ -490,18 +944,34 @@ func (t *Store[P, S, V]) buildKey(partitionKey P, sortKey S) (map[string]types.A
 	}, nil
 }
 
-func (t *Store[P, S, V]) buildUpdate(value V, options *WriteOptions[P, S, V]) (dexp.UpdateBuilder, error) {
+func (t *Store[P, S, V]) buildUpdate(payload *encodedPayload, options *WriteOptions[P, S, V]) (dexp.UpdateBuilder, error) {
 	// increment the version attribute by one
 	update := dexp.Add(dexp.Name(t.fields.versionName), dexp.Value(1))
 
-	val, err := attributevalue.Marshal(value)
-	if err != nil {
-		return update, fmt.Errorf("dynastorev2: failed to marshal value: %w", err)
+	// assign the encoded value to the payload field, along with any attributes required to decode it
+	for k, v := range payload.attributes {
+		update = update.Set(dexp.Name(k), dexp.Value(v))
+	}
+
+	// record the schema version of the payload so it can be upgraded when read in the future
+	if t.storeOptions.schemaVersion > 0 {
+		update = update.Set(dexp.Name(t.fields.schemaVersionName), dexp.Value(t.storeOptions.schemaVersion))
+	}
+
+	for _, k := range payload.remove {
+		update = update.Remove(dexp.Name(k))
+	}
+
+	// clear the tombstone when a record is created in place of a soft deleted record
+	if t.storeOptions.softDeleteRetention > 0 {
+		update = update.Remove(dexp.Name(t.fields.deletedAtName))
 	}
 
-	// assign the value to a field called payload
-	update = update.Set(dexp.Name("payload"), dexp.Value(val))
+	return t.addWriteFields(update, options)
+}
 
+// addWriteFields adds the extra fields and TTL from the write options to the update
+func (t *Store[P, S, V]) addWriteFields(update dexp.UpdateBuilder, options *WriteOptions[P, S, V]) (dexp.UpdateBuilder, error) {
 	// if we have some additional fields merge those into the top level record as long as they don't match the
 	// reserved fields used by the store
 	if options.extraFields != nil {
@@ -519,43 +989,98 @@ func (t *Store[P, S, V]) buildUpdate(value V, options *WriteOptions[P, S, V]) (d
 		}
 	}
 
+	// remove any extra fields which are no longer required, for example to take the record out of a sparse index
+	for _, k := range options.removeFields {
+		if t.isReservedField(k) {
+			return update, ErrReservedField
+		}
+
+		update = update.Remove(dexp.Name(k))
+	}
+
 	// if a TTL assigned set it, otherwise leave the attribute out so it never expires
 	if options.ttl > 0 {
 		ttlVal := time.Now().Add(options.ttl).Unix()
 
-		update = update.Set(dexp.Name("expires"), dexp.Value(ttlVal))
+		update = update.Set(dexp.Name(t.fields.expiresName), dexp.Value(ttlVal))
+	}
+
+	// if the TTL is cleared remove the attribute so the record never expires
+	if options.ttlCleared {
+		update = update.Remove(dexp.Name(t.fields.expiresName))
 	}
 
 	return update, nil
 }
 
+// andConditions combines the conditions using AND, ignoring any which aren't set
+func andConditions(left, right dexp.ConditionBuilder) dexp.ConditionBuilder {
+	switch {
+	case !left.IsSet():
+		return right
+	case !right.IsSet():
+		return left
+	default:
+		return left.And(right)
+	}
+}
+
 func parseLastEvaluatedKey(lastEvaluatedKey string, queryInput *dynamodb.QueryInput) error {
+	startKey, err := decodeStartKey(lastEvaluatedKey)
+	if err != nil {
+		return err
+	}
+
+	queryInput.ExclusiveStartKey = startKey
+
+	return nil
+}
+
+func encodeLastEvaluatedKey(res *dynamodb.QueryOutput) (string, error) {
+	return encodeStartKey(res.LastEvaluatedKey)
+}
+
+// readFilter combines the filter from the read options with a filter which excludes soft deleted records
+func (t *Store[P, S, V]) readFilter(options *ReadOptions[P, S]) dexp.ConditionBuilder {
+	var filter dexp.ConditionBuilder
+
+	if t.storeOptions.softDeleteRetention > 0 && !options.includeDeleted {
+		filter = dexp.AttributeNotExists(dexp.Name(t.fields.deletedAtName))
+	}
+
+	return andConditions(filter, options.filter)
+}
+
+// decodeStartKey decodes a pagination token into the key used to resume a query or scan
+func decodeStartKey(lastEvaluatedKey string) (map[string]types.AttributeValue, error) {
 	data, err := base64.RawURLEncoding.DecodeString(lastEvaluatedKey)
 	if err != nil {
-		return fmt.Errorf("dynastorev2: failed to decode last evaluated key: %w", err)
+		return nil, fmt.Errorf("dynastorev2: failed to decode last evaluated key: %w", err)
 	}
 
 	m := make(map[string]string)
 
 	err = json.Unmarshal(data, &m)
 	if err != nil {
-		return fmt.Errorf("dynastorev2: failed to unmarshal last evaluated key: %w", err)
+		return nil, fmt.Errorf("dynastorev2: failed to unmarshal last evaluated key: %w", err)
 	}
 
-	queryInput.ExclusiveStartKey, err = attributevalue.MarshalMap(&m)
+	startKey, err := attributevalue.MarshalMap(&m)
 	if err != nil {
-		return fmt.Errorf("dynastorev2: failed to marshal map into last evaluated key: %w", err)
+		return nil, fmt.Errorf("dynastorev2: failed to marshal map into last evaluated key: %w", err)
 	}
-	return nil
+
+	return startKey, nil
 }
 
-func encodeLastEvaluatedKey(res *dynamodb.QueryOutput) (string, error) {
-	if res.LastEvaluatedKey == nil {
+// encodeStartKey encodes the last evaluated key of a query or scan into a pagination token
+func encodeStartKey(lastEvaluatedKey map[string]types.AttributeValue) (string, error) {
+	if lastEvaluatedKey == nil {
 		return "", nil
 	}
 
 	m := make(map[string]string)
-	err := attributevalue.UnmarshalMap(res.LastEvaluatedKey, &m)
+	err := attributevalue.UnmarshalMap(lastEvaluatedKey, &m)
 	if err != nil {
 		return "", fmt.Errorf("dynastorev2: failed to unmarshal last evaluated key to map: %w", err)
 	}
@@ -575,6 +1100,13 @@ func (t *Store[P, S, V]) isReservedField(k string) bool {
 		t.fields.expiresName,
 		t.fields.versionName,
 		t.fields.payloadName,
+		t.fields.keyIDName,
+		t.fields.dataKeyName,
+		t.fields.blobKeyName,
+		t.fields.blobChecksumName,
+		t.fields.schemaVersionName,
+		t.fields.deletedAtName,
+		t.fields.deletedExpiresName,
 	}, k)
 }
 
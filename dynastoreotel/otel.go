@@ -0,0 +1,261 @@
+// Package dynastoreotel provides store hooks which produce OpenTelemetry spans and metrics for each call made by a
+// dynastorev2 store to DynamoDB.
+//
+// The hooks are registered with the store using dynastorev2.WithStoreHooks:
+//
+//	hooks, err := dynastoreotel.NewStoreHooks[string, string, []byte]()
+//	if err != nil {
+//		// handle error
+//	}
+//
+//	store := dynastorev2.New(client, "tickets-table", dynastorev2.WithStoreHooks(hooks))
+package dynastoreotel
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/wolfeidau/dynastorev2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/wolfeidau/dynastorev2/dynastoreotel"
+
+// Option configures the store hooks
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// WithTracerProvider sets the tracer provider used to create spans, defaults to the global tracer provider
+func WithTracerProvider(tracerProvider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tracerProvider
+	}
+}
+
+// WithMeterProvider sets the meter provider used to record metrics, defaults to the global meter provider
+func WithMeterProvider(meterProvider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = meterProvider
+	}
+}
+
+type instruments struct {
+	tracer            trace.Tracer
+	duration          metric.Float64Histogram
+	readUnits         metric.Float64Counter
+	writeUnits        metric.Float64Counter
+	conditionFailures metric.Int64Counter
+	throttles         metric.Int64Counter
+	retries           metric.Int64Counter
+}
+
+type callStateCtxKeyType string
+
+const callStateCtxKey callStateCtxKeyType = "callState"
+
+// callState tracks a call to DynamoDB between the request and response hooks
+type callState struct {
+	span  trace.Span
+	attrs []attribute.KeyValue
+}
+
+// NewStoreHooks creates store hooks which start a span for each call made to DynamoDB, and record the duration,
+// consumed capacity, conditional check failures and throttles as metrics.
+//
+// Spans carry the table, store operation, index, number of items in the request and response, and the consumed capacity.
+func NewStoreHooks[P dynastorev2.Key, S dynastorev2.Key, V any](options ...Option) (*dynastorev2.StoreHooks[P, S, V], error) {
+	cfg := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+
+	for _, opt := range options {
+		opt(cfg)
+	}
+
+	inst, err := newInstruments(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynastorev2.StoreHooks[P, S, V]{
+		OnRequest:  inst.onRequest,
+		OnResponse: inst.onResponse,
+		OnRetry:    inst.onRetry,
+	}, nil
+}
+
+func newInstruments(cfg *config) (*instruments, error) {
+	meter := cfg.meterProvider.Meter(instrumentationName)
+
+	inst := &instruments{
+		tracer: cfg.tracerProvider.Tracer(instrumentationName),
+	}
+
+	var err error
+
+	inst.duration, err = meter.Float64Histogram("dynastore.call.duration",
+		metric.WithDescription("Duration of calls made to DynamoDB"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	inst.readUnits, err = meter.Float64Counter("dynastore.consumed.read_units",
+		metric.WithDescription("Read capacity units consumed by calls made to DynamoDB"),
+		metric.WithUnit("{unit}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	inst.writeUnits, err = meter.Float64Counter("dynastore.consumed.write_units",
+		metric.WithDescription("Write capacity units consumed by calls made to DynamoDB"),
+		metric.WithUnit("{unit}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	inst.conditionFailures, err = meter.Int64Counter("dynastore.condition_failures",
+		metric.WithDescription("Calls made to DynamoDB which failed a condition check"),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	inst.throttles, err = meter.Int64Counter("dynastore.throttles",
+		metric.WithDescription("Calls made to DynamoDB which were throttled"),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	inst.retries, err = meter.Int64Counter("dynastore.retries",
+		metric.WithDescription("Calls made to DynamoDB which were retried by the retry policy of the store"),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return inst, nil
+}
+
+func (inst *instruments) onRequest(ctx context.Context, event *dynastorev2.RequestEvent) context.Context {
+	operation := event.Name
+	if operation == "" {
+		operation = event.Call
+	}
+
+	// these attributes are shared by the span and metrics so keep the cardinality low
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "dynamodb"),
+		attribute.String("db.operation", event.Call),
+		attribute.String("dynastore.operation", operation),
+		attribute.String("aws.dynamodb.table_names", event.Table),
+	}
+
+	if event.Index != "" {
+		attrs = append(attrs, attribute.String("aws.dynamodb.index_name", event.Index))
+	}
+
+	ctx, span := inst.tracer.Start(ctx, "dynastore."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+
+	if event.ItemCount > 0 {
+		span.SetAttributes(attribute.Int("dynastore.item_count", event.ItemCount))
+	}
+
+	// the span of the failed attempt has ended by the time the retry policy is consulted so retries are recorded
+	// on the span of the attempt which follows
+	if event.Attempt > 1 {
+		span.SetAttributes(attribute.Int("dynastore.attempt", event.Attempt))
+		span.AddEvent("dynastore.retry", trace.WithAttributes(attribute.Int("dynastore.attempt", event.Attempt)))
+	}
+
+	return context.WithValue(ctx, callStateCtxKey, &callState{
+		span:  span,
+		attrs: attrs,
+	})
+}
+
+func (inst *instruments) onResponse(ctx context.Context, event *dynastorev2.ResponseEvent) {
+	state, ok := ctx.Value(callStateCtxKey).(*callState)
+	if !ok {
+		return
+	}
+
+	defer state.span.End()
+
+	attrs := metric.WithAttributes(state.attrs...)
+
+	inst.duration.Record(ctx, event.Duration.Seconds(), attrs)
+
+	if event.Err != nil {
+		inst.error(ctx, state, event.Err)
+		return
+	}
+
+	units := event.CapacityUnits()
+
+	state.span.SetAttributes(
+		attribute.Float64("aws.dynamodb.consumed_capacity", units),
+		attribute.Int("dynastore.returned_item_count", event.ItemCount),
+	)
+
+	if event.Request.Write {
+		inst.writeUnits.Add(ctx, units, attrs)
+	} else {
+		inst.readUnits.Add(ctx, units, attrs)
+	}
+}
+
+func (inst *instruments) onRetry(ctx context.Context, event *dynastorev2.RetryEvent) {
+	operation := event.Request.Name
+	if operation == "" {
+		operation = event.Request.Call
+	}
+
+	inst.retries.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("db.system", "dynamodb"),
+		attribute.String("db.operation", event.Request.Call),
+		attribute.String("dynastore.operation", operation),
+		attribute.String("aws.dynamodb.table_names", event.Request.Table),
+	))
+}
+
+func (inst *instruments) error(ctx context.Context, state *callState, err error) {
+	attrs := metric.WithAttributes(state.attrs...)
+
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		// a failed condition is an expected outcome of optimistic locking so isn't recorded as an error on the span
+		state.span.SetAttributes(attribute.Bool("dynastore.condition_failed", true))
+		inst.conditionFailures.Add(ctx, 1, attrs)
+
+		return
+	}
+
+	if dynastorev2.IsThrottle(err) {
+		state.span.SetAttributes(attribute.Bool("dynastore.throttled", true))
+		inst.throttles.Add(ctx, 1, attrs)
+	}
+
+	state.span.RecordError(err)
+	state.span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,238 @@
+package dynastoreotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/wolfeidau/dynastorev2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestHooks(t *testing.T) (*tracetest.SpanRecorder, *sdkmetric.ManualReader, func(ctx context.Context, request *dynastorev2.RequestEvent, response *dynastorev2.ResponseEvent)) {
+	recorder := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+
+	hooks, err := NewStoreHooks[string, string, []byte](
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// invoke the hooks in the same order as the store
+	call := func(ctx context.Context, request *dynastorev2.RequestEvent, response *dynastorev2.ResponseEvent) {
+		ctx = hooks.OnRequest(ctx, request)
+
+		response.Request = request
+
+		hooks.OnResponse(ctx, response)
+	}
+
+	return recorder, reader, call
+}
+
+func TestStoreHooksSpans(t *testing.T) {
+	ctx := context.Background()
+
+	recorder, _, call := newTestHooks(t)
+
+	call(ctx,
+		&dynastorev2.RequestEvent{Operation: dynastorev2.Operation{Name: "ListBySortKeyPrefix", Call: "Query", Table: "tickets"}, Index: "idx_created"},
+		&dynastorev2.ResponseEvent{ConsumedCapacity: []types.ConsumedCapacity{{CapacityUnits: aws.Float64(2.5)}}, ItemCount: 3},
+	)
+	call(ctx, updateRequest(), &dynastorev2.ResponseEvent{Err: errors.New("boom")})
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans got %d", len(spans))
+	}
+
+	if name := spans[0].Name(); name != "dynastore.ListBySortKeyPrefix" {
+		t.Errorf("expected span name dynastore.ListBySortKeyPrefix got %s", name)
+	}
+
+	expected := []struct {
+		key   attribute.Key
+		value attribute.Value
+	}{
+		{key: "db.system", value: attribute.StringValue("dynamodb")},
+		{key: "db.operation", value: attribute.StringValue("Query")},
+		{key: "dynastore.operation", value: attribute.StringValue("ListBySortKeyPrefix")},
+		{key: "aws.dynamodb.index_name", value: attribute.StringValue("idx_created")},
+		{key: "aws.dynamodb.table_names", value: attribute.StringValue("tickets")},
+		{key: "aws.dynamodb.consumed_capacity", value: attribute.Float64Value(2.5)},
+		{key: "dynastore.returned_item_count", value: attribute.IntValue(3)},
+	}
+
+	for _, want := range expected {
+		if got := attrValue(spans[0].Attributes(), want.key); got != want.value {
+			t.Errorf("expected %s to be %v got %v", want.key, want.value.Emit(), got.Emit())
+		}
+	}
+
+	if code := spans[1].Status().Code; code != codes.Error {
+		t.Errorf("expected status %v got %v", codes.Error, code)
+	}
+
+	if got := attrValue(spans[1].Attributes(), "dynastore.item_count"); got != attribute.IntValue(1) {
+		t.Errorf("expected dynastore.item_count to be 1 got %v", got.Emit())
+	}
+}
+
+func TestStoreHooksMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	_, reader, call := newTestHooks(t)
+
+	call(ctx,
+		&dynastorev2.RequestEvent{Operation: dynastorev2.Operation{Name: "Get", Call: "GetItem", Table: "tickets"}, ItemCount: 1},
+		&dynastorev2.ResponseEvent{ConsumedCapacity: []types.ConsumedCapacity{{CapacityUnits: aws.Float64(0.5)}}},
+	)
+	call(ctx,
+		&dynastorev2.RequestEvent{Operation: dynastorev2.Operation{Name: "Import", Call: "BatchWriteItem", Table: "tickets"}, Write: true, ItemCount: 3},
+		&dynastorev2.ResponseEvent{ConsumedCapacity: []types.ConsumedCapacity{{CapacityUnits: aws.Float64(3)}}},
+	)
+	call(ctx, updateRequest(), &dynastorev2.ResponseEvent{Err: &types.ConditionalCheckFailedException{}})
+	call(ctx, updateRequest(), &dynastorev2.ResponseEvent{Err: &types.ProvisionedThroughputExceededException{}})
+
+	metrics := collectMetrics(t, reader)
+
+	if got := sumFloat(metrics["dynastore.consumed.read_units"]); got != 0.5 {
+		t.Errorf("expected 0.5 read units got %v", got)
+	}
+
+	if got := sumFloat(metrics["dynastore.consumed.write_units"]); got != 3 {
+		t.Errorf("expected 3 write units got %v", got)
+	}
+
+	if got := sumInt(metrics["dynastore.condition_failures"]); got != 1 {
+		t.Errorf("expected 1 condition failure got %d", got)
+	}
+
+	if got := sumInt(metrics["dynastore.throttles"]); got != 1 {
+		t.Errorf("expected 1 throttle got %d", got)
+	}
+
+	var calls uint64
+	for _, dp := range metrics["dynastore.call.duration"].(metricdata.Histogram[float64]).DataPoints {
+		calls += dp.Count
+	}
+
+	if calls != 4 {
+		t.Errorf("expected 4 calls got %d", calls)
+	}
+}
+
+func TestStoreHooksRetries(t *testing.T) {
+	ctx := context.Background()
+
+	reader := sdkmetric.NewManualReader()
+
+	hooks, err := NewStoreHooks[string, string, []byte](
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hooks.OnRetry(ctx, &dynastorev2.RetryEvent{Request: updateRequest(), Attempt: 1, Err: &types.ProvisionedThroughputExceededException{}})
+	hooks.OnRetry(ctx, &dynastorev2.RetryEvent{Request: updateRequest(), Attempt: 2, Err: &types.ProvisionedThroughputExceededException{}})
+
+	metrics := collectMetrics(t, reader)
+	if len(metrics) != 1 {
+		t.Fatalf("expected 1 metric got %d", len(metrics))
+	}
+
+	if got := sumInt(metrics["dynastore.retries"]); got != 2 {
+		t.Errorf("expected 2 retries got %d", got)
+	}
+}
+
+func TestStoreHooksRetrySpans(t *testing.T) {
+	ctx := context.Background()
+
+	recorder, _, call := newTestHooks(t)
+
+	first, second := updateRequest(), updateRequest()
+	first.Attempt, second.Attempt = 1, 2
+
+	call(ctx, first, &dynastorev2.ResponseEvent{Err: &types.ProvisionedThroughputExceededException{Message: aws.String("slow down")}})
+	call(ctx, second, &dynastorev2.ResponseEvent{})
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans got %d", len(spans))
+	}
+
+	// the retry is recorded on the span of the attempt which follows the failure
+	if events := spans[0].Events(); len(events) != 1 || events[0].Name != "exception" {
+		t.Errorf("expected only an exception event on the failed attempt got %v", events)
+	}
+
+	if got := attrValue(spans[0].Attributes(), "dynastore.throttled"); got != attribute.BoolValue(true) {
+		t.Errorf("expected dynastore.throttled to be true got %v", got.Emit())
+	}
+
+	if events := spans[1].Events(); len(events) != 1 || events[0].Name != "dynastore.retry" {
+		t.Errorf("expected a retry event on the next attempt got %v", events)
+	}
+
+	if got := attrValue(spans[1].Attributes(), "dynastore.attempt"); got != attribute.IntValue(2) {
+		t.Errorf("expected dynastore.attempt to be 2 got %v", got.Emit())
+	}
+}
+
+func attrValue(attrs []attribute.KeyValue, key attribute.Key) attribute.Value {
+	set := attribute.NewSet(attrs...)
+	value, _ := set.Value(key)
+	return value
+}
+
+func collectMetrics(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rm.ScopeMetrics) != 1 {
+		t.Fatalf("expected 1 scope got %d", len(rm.ScopeMetrics))
+	}
+
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m.Data
+	}
+
+	return metrics
+}
+
+func updateRequest() *dynastorev2.RequestEvent {
+	return &dynastorev2.RequestEvent{Operation: dynastorev2.Operation{Name: "Update", Call: "UpdateItem", Table: "tickets"}, Write: true, ItemCount: 1}
+}
+
+func sumFloat(agg metricdata.Aggregation) float64 {
+	var total float64
+	for _, dp := range agg.(metricdata.Sum[float64]).DataPoints {
+		total += dp.Value
+	}
+
+	return total
+}
+
+func sumInt(agg metricdata.Aggregation) int64 {
+	var total int64
+	for _, dp := range agg.(metricdata.Sum[int64]).DataPoints {
+		total += dp.Value
+	}
+
+	return total
+}
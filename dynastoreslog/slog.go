@@ -0,0 +1,173 @@
+// Package dynastoreslog provides store hooks which log each call made by a dynastorev2 store to DynamoDB using log/slog.
+//
+// The hooks are registered with the store using dynastorev2.WithStoreHooks:
+//
+//	hooks := dynastoreslog.NewStoreHooks[string, string, []byte](slog.Default(), dynastoreslog.WithRedactedKeys())
+//
+//	store := dynastorev2.New(client, "tickets-table", dynastorev2.WithStoreHooks(hooks))
+package dynastoreslog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+// Option configures the store hooks
+type Option func(*config)
+
+type config struct {
+	level      slog.Level
+	errorLevel slog.Level
+	retryLevel slog.Level
+	sampleRate float64
+	redact     func(key string) string
+}
+
+// WithLevel sets the level successful calls are logged at, defaults to debug
+func WithLevel(level slog.Level) Option {
+	return func(c *config) {
+		c.level = level
+	}
+}
+
+// WithErrorLevel sets the level failed calls are logged at, defaults to error. Failed condition checks are an expected
+// outcome of optimistic locking so these are logged at the level provided to WithLevel.
+func WithErrorLevel(level slog.Level) Option {
+	return func(c *config) {
+		c.errorLevel = level
+	}
+}
+
+// WithRetryLevel sets the level retries made by the retry policy of the store are logged at, defaults to warn
+func WithRetryLevel(level slog.Level) Option {
+	return func(c *config) {
+		c.retryLevel = level
+	}
+}
+
+// WithSampleRate sets the fraction of successful calls which are logged between 0 and 1, defaults to 1 which logs every
+// call. Failed calls are always logged.
+func WithSampleRate(rate float64) Option {
+	return func(c *config) {
+		c.sampleRate = rate
+	}
+}
+
+// WithKeyRedaction sets a function which is used to redact the partition and sort keys before they are logged
+func WithKeyRedaction(redact func(key string) string) Option {
+	return func(c *config) {
+		c.redact = redact
+	}
+}
+
+// WithRedactedKeys replaces the partition and sort keys with a short SHA-256 hash before they are logged, this allows
+// calls for the same record to be correlated without logging the keys.
+func WithRedactedKeys() Option {
+	return WithKeyRedaction(func(key string) string {
+		sum := sha256.Sum256([]byte(key))
+		return hex.EncodeToString(sum[:6])
+	})
+}
+
+// NewStoreHooks creates store hooks which log each call made to DynamoDB with the operation, keys, duration, consumed
+// capacity and outcome.
+func NewStoreHooks[P dynastorev2.Key, S dynastorev2.Key, V any](logger *slog.Logger, options ...Option) *dynastorev2.StoreHooks[P, S, V] {
+	cfg := &config{
+		level:      slog.LevelDebug,
+		errorLevel: slog.LevelError,
+		retryLevel: slog.LevelWarn,
+		sampleRate: 1,
+	}
+
+	for _, opt := range options {
+		opt(cfg)
+	}
+
+	return &dynastorev2.StoreHooks[P, S, V]{
+		OnResponse: func(ctx context.Context, event *dynastorev2.ResponseEvent) {
+			cfg.log(ctx, logger, event)
+		},
+		OnRetry: func(ctx context.Context, event *dynastorev2.RetryEvent) {
+			cfg.logRetry(ctx, logger, event)
+		},
+	}
+}
+
+func (cfg *config) log(ctx context.Context, logger *slog.Logger, event *dynastorev2.ResponseEvent) {
+	level := cfg.level
+	outcome := "ok"
+
+	var ccf *types.ConditionalCheckFailedException
+
+	switch {
+	case event.Err == nil:
+		if cfg.sampleRate < 1 && rand.Float64() >= cfg.sampleRate {
+			return
+		}
+	case errors.As(event.Err, &ccf):
+		outcome = "condition_failed"
+	default:
+		level = cfg.errorLevel
+		outcome = "error"
+	}
+
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	req := event.Request
+
+	attrs := []slog.Attr{
+		slog.String("operation", req.Name),
+		slog.String("call", req.Call),
+		slog.String("partition_key", cfg.redactKey(req.PartitionKey)),
+		slog.String("sort_key", cfg.redactKey(req.SortKey)),
+		slog.Duration("duration", event.Duration),
+		slog.Float64("consumed_capacity", event.CapacityUnits()),
+		slog.Int("item_count", event.ItemCount),
+		slog.String("outcome", outcome),
+	}
+
+	if req.Index != "" {
+		attrs = append(attrs, slog.String("index", req.Index))
+	}
+
+	if outcome == "error" {
+		attrs = append(attrs, slog.String("error", event.Err.Error()))
+	}
+
+	logger.LogAttrs(ctx, level, "dynastore call", attrs...)
+}
+
+func (cfg *config) logRetry(ctx context.Context, logger *slog.Logger, event *dynastorev2.RetryEvent) {
+	if !logger.Enabled(ctx, cfg.retryLevel) {
+		return
+	}
+
+	req := event.Request
+
+	logger.LogAttrs(ctx, cfg.retryLevel, "dynastore retry",
+		slog.String("operation", req.Name),
+		slog.String("call", req.Call),
+		slog.String("partition_key", cfg.redactKey(req.PartitionKey)),
+		slog.String("sort_key", cfg.redactKey(req.SortKey)),
+		slog.Int("attempt", event.Attempt),
+		slog.Duration("delay", event.Delay),
+		slog.String("error", event.Err.Error()),
+	)
+}
+
+func (cfg *config) redactKey(key string) string {
+	if cfg.redact == nil || key == "" {
+		return key
+	}
+
+	return cfg.redact(key)
+}
@@ -0,0 +1,127 @@
+package dynastoreslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+func newTestHooks(buf *bytes.Buffer, options ...Option) func(call string, response *dynastorev2.ResponseEvent) {
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	hooks := NewStoreHooks[string, string, []byte](logger, options...)
+
+	return func(call string, response *dynastorev2.ResponseEvent) {
+		response.Request = &dynastorev2.RequestEvent{Operation: dynastorev2.Operation{Name: "Test", Call: call, PartitionKey: "part", SortKey: "sort"}}
+
+		hooks.OnResponse(context.Background(), response)
+	}
+}
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		entry := make(map[string]any)
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+
+		lines = append(lines, entry)
+	}
+
+	return lines
+}
+
+func TestStoreHooks(t *testing.T) {
+	buf := new(bytes.Buffer)
+	call := newTestHooks(buf)
+
+	call("GetItem", &dynastorev2.ResponseEvent{ConsumedCapacity: []types.ConsumedCapacity{{CapacityUnits: aws.Float64(0.5)}}, ItemCount: 1})
+	call("UpdateItem", &dynastorev2.ResponseEvent{Err: &types.ConditionalCheckFailedException{}})
+	call("Query", &dynastorev2.ResponseEvent{Err: errors.New("boom")})
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 log entries got %d", len(lines))
+	}
+
+	expected := []struct {
+		level   string
+		call    string
+		outcome string
+	}{
+		{"DEBUG", "GetItem", "ok"},
+		{"DEBUG", "UpdateItem", "condition_failed"},
+		{"ERROR", "Query", "error"},
+	}
+
+	for i, exp := range expected {
+		if lines[i]["level"] != exp.level || lines[i]["call"] != exp.call || lines[i]["outcome"] != exp.outcome {
+			t.Errorf("unexpected log entry %d: %v", i, lines[i])
+		}
+	}
+
+	if lines[0]["consumed_capacity"] != 0.5 || lines[0]["item_count"] != 1.0 || lines[0]["partition_key"] != "part" {
+		t.Errorf("expected consumed capacity, item count and keys to be logged: %v", lines[0])
+	}
+
+	if lines[2]["error"] != "boom" {
+		t.Errorf("expected error to be logged: %v", lines[2])
+	}
+}
+
+func TestStoreHooksSampling(t *testing.T) {
+	buf := new(bytes.Buffer)
+	call := newTestHooks(buf, WithSampleRate(0), WithLevel(slog.LevelInfo))
+
+	call("GetItem", &dynastorev2.ResponseEvent{})
+	call("GetItem", &dynastorev2.ResponseEvent{Err: errors.New("boom")})
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 || lines[0]["outcome"] != "error" {
+		t.Fatalf("expected only the failed call to be logged: %v", lines)
+	}
+}
+
+func TestStoreHooksRetry(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	hooks := NewStoreHooks[string, string, []byte](logger)
+
+	hooks.OnRetry(context.Background(), &dynastorev2.RetryEvent{
+		Request: &dynastorev2.RequestEvent{Operation: dynastorev2.Operation{Name: "Get", Call: "GetItem"}},
+		Attempt: 1,
+		Err:     errors.New("throttled"),
+	})
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 1 || lines[0]["level"] != "WARN" || lines[0]["attempt"] != 1.0 || lines[0]["error"] != "throttled" {
+		t.Fatalf("expected retry to be logged: %v", lines)
+	}
+}
+
+func TestRedactedKeys(t *testing.T) {
+	cfg := &config{}
+	WithRedactedKeys()(cfg)
+
+	redacted := cfg.redactKey("customer-1234")
+	if redacted == "customer-1234" || len(redacted) != 12 {
+		t.Errorf("expected key to be redacted: %s", redacted)
+	}
+
+	if cfg.redactKey("") != "" {
+		t.Error("expected empty key to be left as is")
+	}
+}
@@ -0,0 +1,176 @@
+package dynastorev2
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const dataKeySize = 32
+
+// KeyProvider wraps and unwraps the per item data keys used to encrypt payloads when envelope encryption is enabled.
+//
+// To support key rotation the provider returns the identifier of the key used to wrap each data key, this is stored
+// alongside the item and passed back to UnwrapKey when it is read.
+type KeyProvider interface {
+	// WrapKey encrypts the data key using the current key, returning the identifier of that key and the wrapped data key
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrappedKey []byte, err error)
+	// UnwrapKey decrypts a data key which was previously wrapped using the key with the provided identifier
+	UnwrapKey(ctx context.Context, keyID string, wrappedKey []byte) ([]byte, error)
+}
+
+// StaticKeyProvider is a KeyProvider which wraps data keys using AES-GCM with a set of static keys held in memory,
+// this is intended for testing and local development.
+type StaticKeyProvider struct {
+	currentKeyID string
+	keys         map[string]cipher.AEAD
+}
+
+// NewStaticKeyProvider creates a static key provider which wraps new data keys with the key identified by currentKeyID,
+// any other keys provided are retained to unwrap data keys after a rotation.
+//
+// Each key must be 16, 24 or 32 bytes to select AES-128, AES-192 or AES-256.
+func NewStaticKeyProvider(currentKeyID string, keys map[string][]byte) (*StaticKeyProvider, error) {
+	if _, ok := keys[currentKeyID]; !ok {
+		return nil, fmt.Errorf("dynastorev2: current key %q not found in provided keys", currentKeyID)
+	}
+
+	kp := &StaticKeyProvider{
+		currentKeyID: currentKeyID,
+		keys:         make(map[string]cipher.AEAD, len(keys)),
+	}
+
+	for keyID, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("dynastorev2: invalid key %q: %w", keyID, err)
+		}
+
+		kp.keys[keyID] = aead
+	}
+
+	return kp, nil
+}
+
+// WrapKey encrypts the data key using the current key
+func (kp *StaticKeyProvider) WrapKey(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	wrappedKey, err := seal(kp.keys[kp.currentKeyID], dataKey, []byte(kp.currentKeyID))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return kp.currentKeyID, wrappedKey, nil
+}
+
+// UnwrapKey decrypts the data key using the key with the provided identifier
+func (kp *StaticKeyProvider) UnwrapKey(ctx context.Context, keyID string, wrappedKey []byte) ([]byte, error) {
+	aead, ok := kp.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("dynastorev2: key %q not found", keyID)
+	}
+
+	return open(aead, wrappedKey, []byte(keyID))
+}
+
+// encryptPayload encrypts the payload using a newly generated data key which is wrapped by the key provider
+func encryptPayload(ctx context.Context, kp KeyProvider, plaintext, aad []byte) (ciphertext []byte, keyID string, wrappedKey []byte, err error) {
+	dataKey := make([]byte, dataKeySize)
+
+	_, err = rand.Read(dataKey)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("dynastorev2: failed to generate data key: %w", err)
+	}
+
+	keyID, wrappedKey, err = kp.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("dynastorev2: failed to wrap data key: %w", err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	ciphertext, err = seal(aead, plaintext, aad)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return ciphertext, keyID, wrappedKey, nil
+}
+
+// decryptPayload unwraps the data key using the key provider then decrypts the payload
+func decryptPayload(ctx context.Context, kp KeyProvider, ciphertext []byte, keyID string, wrappedKey []byte, aad []byte) ([]byte, error) {
+	dataKey, err := kp.UnwrapKey(ctx, keyID, wrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to unwrap data key: %w", err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(aead, ciphertext, aad)
+}
+
+// keyAAD builds the additional authenticated data from the partition and sort key attributes, this binds the
+// encrypted payload to the item it was written to
+func keyAAD(pk, sk types.AttributeValue) ([]byte, error) {
+	var aad []byte
+
+	for _, av := range []types.AttributeValue{pk, sk} {
+		data, err := encodeAttributeValue(av)
+		if err != nil {
+			return nil, fmt.Errorf("dynastorev2: failed to encode key: %w", err)
+		}
+
+		aad = binary.AppendUvarint(aad, uint64(len(data)))
+		aad = append(aad, data...)
+	}
+
+	return aad, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts the plaintext returning the nonce followed by the ciphertext
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to generate nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// open decrypts a value produced by seal
+func open(aead cipher.AEAD, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("dynastorev2: ciphertext is too short")
+	}
+
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to decrypt: %w", err)
+	}
+
+	return plaintext, nil
+}
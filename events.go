@@ -0,0 +1,159 @@
+package dynastorev2
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// RequestEvent describes a request which is about to be sent to DynamoDB
+type RequestEvent struct {
+	Operation
+	// Index the request is made against, empty for the table
+	Index string
+	// Write is true if the request modifies items in the table
+	Write bool
+	// ItemCount is the number of items the request reads or writes by key, zero for queries and scans
+	ItemCount int
+	// Attempt number of the request, starting at 1 and incremented each time the request is retried
+	Attempt int
+	// KeyConditionExpression used by queries
+	KeyConditionExpression string
+	// ConditionExpression used by conditional writes
+	ConditionExpression string
+	// FilterExpression used by queries and scans
+	FilterExpression string
+	// UpdateExpression used by updates
+	UpdateExpression string
+	// ExpressionAttributeNames substituted for the name placeholders in the expressions
+	ExpressionAttributeNames map[string]string
+	// ExpressionAttributeValues substituted for the value placeholders in the expressions
+	ExpressionAttributeValues map[string]types.AttributeValue
+}
+
+// ResponseEvent describes the outcome of a request sent to DynamoDB
+type ResponseEvent struct {
+	// Request which was sent to DynamoDB
+	Request *RequestEvent
+	// Duration of the call to the AWS SDK
+	Duration time.Duration
+	// ConsumedCapacity returned by DynamoDB, empty if the call failed or capacity wasn't returned
+	ConsumedCapacity []types.ConsumedCapacity
+	// ItemCount is the number of items returned or written by the request
+	ItemCount int
+	// Err returned by the AWS SDK, including failed conditions
+	Err error
+	// Output is the AWS SDK output for the call, for example *dynamodb.UpdateItemOutput, nil if the call failed
+	Output any
+}
+
+// CapacityUnits returns the total capacity units consumed by the request
+func (e *ResponseEvent) CapacityUnits() float64 {
+	var total float64
+
+	for _, cc := range e.ConsumedCapacity {
+		total += aws.ToFloat64(cc.CapacityUnits)
+	}
+
+	return total
+}
+
+// newRequestEvent describes the request using the operation details in the context and the AWS SDK input
+func newRequestEvent(ctx context.Context, tableName string, params any) *RequestEvent {
+	event := &RequestEvent{
+		Operation: Operation{
+			Call:  "Unknown",
+			Table: tableName,
+			Input: params,
+		},
+	}
+
+	if details := OperationDetailsFromContext(ctx); details != nil {
+		event.Name, event.PartitionKey, event.SortKey = details.Name, details.PartitionKey, details.SortKey
+	}
+
+	switch in := params.(type) {
+	case *dynamodb.GetItemInput:
+		event.Call, event.ItemCount = "GetItem", 1
+	case *dynamodb.QueryInput:
+		event.Call = "Query"
+		event.Index = aws.ToString(in.IndexName)
+		event.KeyConditionExpression = aws.ToString(in.KeyConditionExpression)
+		event.FilterExpression = aws.ToString(in.FilterExpression)
+		event.ExpressionAttributeNames, event.ExpressionAttributeValues = in.ExpressionAttributeNames, in.ExpressionAttributeValues
+	case *dynamodb.ScanInput:
+		event.Call = "Scan"
+		event.Index = aws.ToString(in.IndexName)
+		event.FilterExpression = aws.ToString(in.FilterExpression)
+		event.ExpressionAttributeNames, event.ExpressionAttributeValues = in.ExpressionAttributeNames, in.ExpressionAttributeValues
+	case *dynamodb.PutItemInput:
+		event.Call, event.Write, event.ItemCount = "PutItem", true, 1
+		event.ConditionExpression = aws.ToString(in.ConditionExpression)
+		event.ExpressionAttributeNames, event.ExpressionAttributeValues = in.ExpressionAttributeNames, in.ExpressionAttributeValues
+	case *dynamodb.UpdateItemInput:
+		event.Call, event.Write, event.ItemCount = "UpdateItem", true, 1
+		event.ConditionExpression = aws.ToString(in.ConditionExpression)
+		event.UpdateExpression = aws.ToString(in.UpdateExpression)
+		event.ExpressionAttributeNames, event.ExpressionAttributeValues = in.ExpressionAttributeNames, in.ExpressionAttributeValues
+	case *dynamodb.DeleteItemInput:
+		event.Call, event.Write, event.ItemCount = "DeleteItem", true, 1
+		event.ConditionExpression = aws.ToString(in.ConditionExpression)
+		event.ExpressionAttributeNames, event.ExpressionAttributeValues = in.ExpressionAttributeNames, in.ExpressionAttributeValues
+	case *dynamodb.BatchWriteItemInput:
+		event.Call, event.Write = "BatchWriteItem", true
+
+		for _, requests := range in.RequestItems {
+			event.ItemCount += len(requests)
+		}
+	}
+
+	return event
+}
+
+// newResponseEvent describes the outcome of the request using the AWS SDK output
+func newResponseEvent(request *RequestEvent, out any, err error, duration time.Duration) *ResponseEvent {
+	event := &ResponseEvent{
+		Request:  request,
+		Duration: duration,
+		Err:      err,
+	}
+
+	if err != nil {
+		return event
+	}
+
+	event.Output = out
+
+	switch res := out.(type) {
+	case *dynamodb.GetItemOutput:
+		if len(res.Item) > 0 {
+			event.ItemCount = 1
+		}
+	case *dynamodb.QueryOutput:
+		event.ItemCount = int(res.Count)
+	case *dynamodb.ScanOutput:
+		event.ItemCount = int(res.Count)
+	case *dynamodb.PutItemOutput, *dynamodb.UpdateItemOutput, *dynamodb.DeleteItemOutput:
+		event.ItemCount = 1
+	case *dynamodb.BatchWriteItemOutput:
+		event.ItemCount = request.ItemCount
+
+		for _, requests := range res.UnprocessedItems {
+			event.ItemCount -= len(requests)
+		}
+	}
+
+	switch cc := consumedCapacity(out).(type) {
+	case *types.ConsumedCapacity:
+		if cc != nil {
+			event.ConsumedCapacity = []types.ConsumedCapacity{*cc}
+		}
+	case []types.ConsumedCapacity:
+		event.ConsumedCapacity = cc
+	}
+
+	return event
+}
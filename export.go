@@ -0,0 +1,417 @@
+package dynastorev2
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	dexp "github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// exportRecord is a Record as written by Export, the extra fields are encoded as DynamoDB JSON so their types, such as
+// sets, binary values and large numbers, are retained when they are imported
+type exportRecord[P Key, S Key, V any] struct {
+	PartitionKey P                          `json:"partition_key"`
+	SortKey      S                          `json:"sort_key"`
+	Version      int64                      `json:"version"`
+	Expires      *time.Time                 `json:"expires,omitempty"`
+	Fields       map[string]json.RawMessage `json:"fields,omitempty"`
+	Deleted      *exportTombstone           `json:"deleted,omitempty"`
+	Value        V                          `json:"value"`
+}
+
+// exportTombstone is the tombstone of a soft deleted record, holding the time it was deleted and the TTL it had before
+// it was deleted, so records exported using ReadWithDeleted(true) are imported as deleted and can still be restored
+type exportTombstone struct {
+	At      time.Time  `json:"at"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// Export writes every record in the table to the writer as newline delimited JSON, one Record per line with the extra
+// fields encoded as DynamoDB JSON, returning the number of records exported. The table is read using Scan so the
+// segment and concurrency options apply, and records are written in no particular order.
+func (t *Store[P, S, V]) Export(ctx context.Context, w io.Writer, options ...ReadOption[P, S]) (*OperationResult, int, error) {
+	ctx = setOperationDetails(ctx, "Export", "", "")
+
+	var (
+		mu    sync.Mutex
+		count int
+	)
+
+	enc := json.NewEncoder(w)
+
+	res, err := t.scan(ctx, func(ctx context.Context, item map[string]types.AttributeValue, record Record[P, S, V]) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		err := t.writeExportRecord(enc, item, record)
+		if err != nil {
+			return err
+		}
+
+		count++
+
+		return nil
+	}, options...)
+	if err != nil {
+		return nil, count, err
+	}
+
+	return res, count, nil
+}
+
+// ExportPartition writes the records with the provided partition key to the writer as newline delimited JSON, one
+// Record per line in sort key order, returning the number of records exported.
+func (t *Store[P, S, V]) ExportPartition(ctx context.Context, w io.Writer, partitionKey P, options ...ReadOption[P, S]) (*OperationResult, int, error) {
+	ctx = setOperationDetails(ctx, "ExportPartition", partitionKey, "")
+
+	keyCond := dexp.KeyEqual(dexp.Key(t.fields.partitionKeyName), dexp.Value(partitionKey))
+
+	return t.exportQuery(ctx, w, partitionKey, keyCond, options...)
+}
+
+// ExportBySortKeyPrefix writes the records with the provided partition key and a sort key starting with the prefix to
+// the writer as newline delimited JSON, one Record per line in sort key order, returning the number of records exported.
+func (t *Store[P, S, V]) ExportBySortKeyPrefix(ctx context.Context, w io.Writer, partitionKey P, prefix string, options ...ReadOption[P, S]) (*OperationResult, int, error) {
+	ctx = setOperationDetails(ctx, "ExportBySortKeyPrefix", partitionKey, prefix)
+
+	keyCond := dexp.KeyEqual(dexp.Key(t.fields.partitionKeyName), dexp.Value(partitionKey)).
+		And(dexp.KeyBeginsWith(dexp.Key(t.fields.sortKeyName), prefix))
+
+	return t.exportQuery(ctx, w, partitionKey, keyCond, options...)
+}
+
+// Import reads newline delimited JSON records, as written by Export, from the reader and writes them to the table
+// using batched writes, returning the number of records imported.
+//
+// Notes:
+// 1. Existing records with the same keys are replaced, and the version, expiry and extra fields from each record are retained.
+// 2. Values are validated and encoded as they would be by Create, so encryption and blob offload apply.
+// 3. If an error occurs the records in batches which have already been written are retained.
+// 4. If the same keys appear more than once the last record read is retained.
+// 5. Soft deleted records, exported using ReadWithDeleted(true), are imported with their tombstone.
+func (t *Store[P, S, V]) Import(ctx context.Context, r io.Reader) (*OperationResult, int, error) {
+	ctx = setOperationDetails(ctx, "Import", "", "")
+
+	var (
+		count    int
+		line     int
+		requests []types.WriteRequest
+		records  []Record[P, S, V]
+		capacity = &types.ConsumedCapacity{TableName: aws.String(t.tableName), CapacityUnits: aws.Float64(0)}
+		// keys in the current batch, as BatchWriteItem rejects a batch which writes the same item more than once
+		batchKeys = make(map[string]struct{}, batchWriteMaxItems)
+	)
+
+	flush := func() error {
+		used, err := t.batchWrite(ctx, records[0].PartitionKey, records[0].SortKey, requests)
+		addCapacity(capacity, used...)
+
+		for _, record := range records {
+			t.invalidateCachedRecord(ctx, record.PartitionKey, record.SortKey)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		count += len(requests)
+		requests, records = requests[:0], records[:0]
+		clear(batchKeys)
+
+		return nil
+	}
+
+	br := bufio.NewReader(r)
+
+	for {
+		data, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, count, fmt.Errorf("dynastorev2: failed to read records: %w", err)
+		}
+
+		line++
+
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			var record Record[P, S, V]
+
+			tombstone, jerr := readExportRecord(data, &record)
+			if jerr != nil {
+				return nil, count, fmt.Errorf("dynastorev2: failed to unmarshal record on line %d: %w", line, jerr)
+			}
+
+			item, ierr := t.buildRecordItem(ctx, record)
+			if ierr != nil {
+				return nil, count, fmt.Errorf("dynastorev2: failed to import record on line %d: %w", line, ierr)
+			}
+
+			if tombstone != nil {
+				var expires int64
+				if tombstone.Expires != nil {
+					expires = tombstone.Expires.Unix()
+				}
+
+				item[t.fields.deletedAtName] = &types.AttributeValueMemberN{Value: fmt.Sprint(tombstone.At.Unix())}
+				item[t.fields.deletedExpiresName] = &types.AttributeValueMemberN{Value: fmt.Sprint(expires)}
+			}
+
+			// a repeated key is written in the next batch so the last record read is retained
+			batchKey := fmt.Sprintf("%v\x00%v", record.PartitionKey, record.SortKey)
+			if _, ok := batchKeys[batchKey]; ok {
+				if ferr := flush(); ferr != nil {
+					return nil, count, ferr
+				}
+			}
+
+			batchKeys[batchKey] = struct{}{}
+
+			requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+			records = append(records, record)
+
+			if len(requests) == batchWriteMaxItems {
+				if ferr := flush(); ferr != nil {
+					return nil, count, ferr
+				}
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	if len(requests) > 0 {
+		if err := flush(); err != nil {
+			return nil, count, err
+		}
+	}
+
+	return &OperationResult{
+		ConsumedCapacity: t.totalCapacity(capacity),
+	}, count, nil
+}
+
+func (t *Store[P, S, V]) exportQuery(ctx context.Context, w io.Writer, partitionKey P, keyCond dexp.KeyConditionBuilder, options ...ReadOption[P, S]) (*OperationResult, int, error) {
+	defaultOpts := t.defaultReadOptions()
+	ApplyReadOptions(defaultOpts, options...)
+
+	builder := dexp.NewBuilder().WithKeyCondition(keyCond)
+
+	if filter := t.readFilter(defaultOpts); filter.IsSet() {
+		builder = builder.WithFilter(filter)
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, 0, fmt.Errorf("dynastorev2: failed to build export expression: %w", err)
+	}
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:                 aws.String(t.tableName),
+		ReturnConsumedCapacity:    t.storeOptions.consumedCapacity,
+		ConsistentRead:            aws.Bool(defaultOpts.consistentRead),
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	enc := json.NewEncoder(w)
+	capacity := &types.ConsumedCapacity{TableName: aws.String(t.tableName), CapacityUnits: aws.Float64(0)}
+	count := 0
+
+	var sortKey S
+
+	for {
+		res, err := send(ctx, t, partitionKey, sortKey, queryInput, t.client.Query)
+		if err != nil {
+			return nil, count, fmt.Errorf("dynastorev2: failed to execute query: %w", err)
+		}
+
+		if res.ConsumedCapacity != nil {
+			addCapacity(capacity, *res.ConsumedCapacity)
+		}
+
+		for _, item := range res.Items {
+			record, _, err := t.decodeRecord(ctx, item)
+			if err != nil {
+				return nil, count, err
+			}
+
+			err = t.writeExportRecord(enc, item, record)
+			if err != nil {
+				return nil, count, err
+			}
+
+			count++
+		}
+
+		if len(res.LastEvaluatedKey) == 0 {
+			break
+		}
+
+		queryInput.ExclusiveStartKey = res.LastEvaluatedKey
+	}
+
+	return &OperationResult{
+		ConsumedCapacity: t.totalCapacity(capacity),
+	}, count, nil
+}
+
+// buildRecordItem validates and encodes the record into an item containing the keys, version, expiry, extra fields
+// and payload
+func (t *Store[P, S, V]) buildRecordItem(ctx context.Context, record Record[P, S, V]) (map[string]types.AttributeValue, error) {
+	err := t.validate(record.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := t.buildKey(record.PartitionKey, record.SortKey)
+	if err != nil {
+		return nil, err
+	}
+
+	item := make(map[string]types.AttributeValue, len(key)+len(record.Fields)+6)
+
+	for k, v := range record.Fields {
+		if t.isReservedField(k) {
+			return nil, fmt.Errorf("%w: %s", ErrReservedField, k)
+		}
+
+		// fields read by Import are already encoded
+		if val, ok := v.(types.AttributeValue); ok {
+			item[k] = val
+			continue
+		}
+
+		val, err := attributevalue.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("dynastorev2: failed to marshal extra field: %w", err)
+		}
+
+		item[k] = val
+	}
+
+	// the payload is encoded last as it may be offloaded to the blob store
+	payload, err := t.encodePayload(ctx, key, record.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range key {
+		item[k] = v
+	}
+
+	for k, v := range payload.attributes {
+		item[k] = v
+	}
+
+	item[t.fields.versionName] = &types.AttributeValueMemberN{Value: fmt.Sprint(record.Version)}
+
+	if record.Expires != nil {
+		item[t.fields.expiresName] = &types.AttributeValueMemberN{Value: fmt.Sprint(record.Expires.Unix())}
+	}
+
+	if t.storeOptions.schemaVersion > 0 {
+		item[t.fields.schemaVersionName] = &types.AttributeValueMemberN{Value: fmt.Sprint(t.storeOptions.schemaVersion)}
+	}
+
+	return item, nil
+}
+
+// writeExportRecord writes the record with the extra fields encoded from the item as DynamoDB JSON
+func (t *Store[P, S, V]) writeExportRecord(enc *json.Encoder, item map[string]types.AttributeValue, record Record[P, S, V]) error {
+	export := exportRecord[P, S, V]{
+		PartitionKey: record.PartitionKey,
+		SortKey:      record.SortKey,
+		Version:      record.Version,
+		Expires:      record.Expires,
+		Value:        record.Value,
+	}
+
+	if t.isDeleted(item) {
+		var deletedAt, deletedExpires int64
+
+		if err := attributevalue.Unmarshal(item[t.fields.deletedAtName], &deletedAt); err != nil {
+			return fmt.Errorf("dynastorev2: failed to extract deleted at attribute: %w", err)
+		}
+
+		export.Deleted = &exportTombstone{At: time.Unix(deletedAt, 0).UTC()}
+
+		// the TTL saved in the tombstone is zero if the record didn't expire
+		if attr, ok := item[t.fields.deletedExpiresName]; ok {
+			if err := attributevalue.Unmarshal(attr, &deletedExpires); err != nil {
+				return fmt.Errorf("dynastorev2: failed to extract deleted expires attribute: %w", err)
+			}
+		}
+
+		if deletedExpires > 0 {
+			expires := time.Unix(deletedExpires, 0).UTC()
+			export.Deleted.Expires = &expires
+		}
+	}
+
+	for k := range record.Fields {
+		data, err := encodeAttributeValue(item[k])
+		if err != nil {
+			return fmt.Errorf("dynastorev2: failed to encode extra field: %w", err)
+		}
+
+		if export.Fields == nil {
+			export.Fields = make(map[string]json.RawMessage, len(record.Fields))
+		}
+
+		export.Fields[k] = data
+	}
+
+	err := enc.Encode(&export)
+	if err != nil {
+		return fmt.Errorf("dynastorev2: failed to write record: %w", err)
+	}
+
+	return nil
+}
+
+// readExportRecord reads a record written by Export, the extra fields are decoded into attribute values. The tombstone
+// is returned if the record was soft deleted.
+func readExportRecord[P Key, S Key, V any](data []byte, record *Record[P, S, V]) (*exportTombstone, error) {
+	var export exportRecord[P, S, V]
+
+	err := json.Unmarshal(data, &export)
+	if err != nil {
+		return nil, err
+	}
+
+	*record = Record[P, S, V]{
+		PartitionKey: export.PartitionKey,
+		SortKey:      export.SortKey,
+		Version:      export.Version,
+		Expires:      export.Expires,
+		Value:        export.Value,
+	}
+
+	for k, v := range export.Fields {
+		val, err := decodeAttributeValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("dynastorev2: failed to decode extra field %s: %w", k, err)
+		}
+
+		if record.Fields == nil {
+			record.Fields = make(map[string]any, len(export.Fields))
+		}
+
+		record.Fields[k] = val
+	}
+
+	return export.Deleted, nil
+}
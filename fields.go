@@ -0,0 +1,269 @@
+package dynastorev2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	dexp "github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"golang.org/x/exp/constraints"
+)
+
+type fieldOperation int
+
+const (
+	fieldSet fieldOperation = iota
+	fieldRemove
+	fieldIncrement
+	fieldAppend
+	fieldPrepend
+	fieldAddToSet
+	fieldDeleteFromSet
+)
+
+// FieldUpdate describes a change to an individual field within the payload or the extra fields of a record, including
+// list and set operations which allow many writers to modify the same field without conflicts.
+//
+// Paths use the DynamoDB document path syntax, for example "status" for an extra field, or "payload.status" for a
+// field within the payload which can be built using PayloadField.
+type FieldUpdate struct {
+	operation fieldOperation
+	path      string
+	value     any
+}
+
+// SetField assigns the value to the field at the path
+func SetField(path string, value any) FieldUpdate {
+	return FieldUpdate{operation: fieldSet, path: path, value: value}
+}
+
+// RemoveField removes the field at the path
+func RemoveField(path string) FieldUpdate {
+	return FieldUpdate{operation: fieldRemove, path: path}
+}
+
+// IncrementField adds the delta to the number at the path, a missing field is treated as zero
+func IncrementField(path string, delta int64) FieldUpdate {
+	return FieldUpdate{operation: fieldIncrement, path: path, value: delta}
+}
+
+// AppendToField appends the values to the list at the path, a missing field is treated as an empty list
+func AppendToField(path string, values ...any) FieldUpdate {
+	return FieldUpdate{operation: fieldAppend, path: path, value: values}
+}
+
+// PrependToField prepends the values to the list at the path, a missing field is treated as an empty list
+func PrependToField(path string, values ...any) FieldUpdate {
+	return FieldUpdate{operation: fieldPrepend, path: path, value: values}
+}
+
+// RemoveListElement removes the element at the index from the list at the path
+func RemoveListElement(path string, index int) FieldUpdate {
+	return FieldUpdate{operation: fieldRemove, path: fmt.Sprintf("%s[%d]", path, index)}
+}
+
+// AddToSet adds the values in the set to the set at the path, a missing field is created with the values.
+//
+// Note DynamoDB only supports set operations on top level attributes.
+func AddToSet(path string, set SetValue) FieldUpdate {
+	return FieldUpdate{operation: fieldAddToSet, path: path, value: set}
+}
+
+// DeleteFromSet removes the values in the set from the set at the path, if the set becomes empty the field is removed.
+//
+// Note DynamoDB only supports set operations on top level attributes.
+func DeleteFromSet(path string, set SetValue) FieldUpdate {
+	return FieldUpdate{operation: fieldDeleteFromSet, path: path, value: set}
+}
+
+// SetValue holds the values of a DynamoDB string, number or binary set for use with AddToSet and DeleteFromSet
+type SetValue struct {
+	value types.AttributeValue
+	size  int
+}
+
+// StringSet creates a DynamoDB string set containing the values
+func StringSet(values ...string) SetValue {
+	return SetValue{value: &types.AttributeValueMemberSS{Value: values}, size: len(values)}
+}
+
+// NumberSet creates a DynamoDB number set containing the values
+func NumberSet[N constraints.Integer | constraints.Float](values ...N) SetValue {
+	numbers := make([]string, 0, len(values))
+
+	for _, v := range values {
+		// numbers are always marshalled to the N type so the error can be ignored
+		av, _ := attributevalue.Marshal(v)
+		if n, ok := av.(*types.AttributeValueMemberN); ok {
+			numbers = append(numbers, n.Value)
+		}
+	}
+
+	return SetValue{value: &types.AttributeValueMemberNS{Value: numbers}, size: len(numbers)}
+}
+
+// BinarySet creates a DynamoDB binary set containing the values
+func BinarySet(values ...[]byte) SetValue {
+	return SetValue{value: &types.AttributeValueMemberBS{Value: values}, size: len(values)}
+}
+
+// PayloadField returns the path of a field within the payload, for use with field updates and conditions
+func (t *Store[P, S, V]) PayloadField(name string) string {
+	return t.fields.payloadName + "." + name
+}
+
+// UpdateFields applies partial updates to individual fields within the payload or the extra fields of a record in
+// DynamoDB using the provided partition and sort keys, this avoids concurrent writers which modify different fields
+// from overwriting each other.
+//
+// Notes:
+// 1. The payload must be stored as a map to update fields within it, so this isn't supported with encryption or blob offload.
+// 2. The version is incremented and the condition from WriteWithVersion is applied, as with Update.
+// 3. This will use a condition to ensure the specified partition and sort keys exist in DynamoDB.
+func (t *Store[P, S, V]) UpdateFields(ctx context.Context, partitionKey P, sortKey S, updates []FieldUpdate, options ...WriteOption[P, S, V]) (*OperationResult, error) {
+	ctx = setOperationDetails(ctx, "UpdateFields", partitionKey, sortKey)
+
+	defaultOpts := t.defaultWriteOptions()
+	ApplyWriteOptions(defaultOpts, options...)
+
+	if err := t.checkCondition(defaultOpts.condition); err != nil {
+		return nil, err
+	}
+
+	// increment the version attribute by one
+	update := dexp.Add(dexp.Name(t.fields.versionName), dexp.Value(1))
+
+	update, err := t.addFieldUpdates(update, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	update, err = t.addWriteFields(update, defaultOpts)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to build update: %w", err)
+	}
+
+	// assign a condition which requires the record to existing before being updated
+	updateCondition := t.existsCondition()
+
+	if defaultOpts.version > 0 {
+		updateCondition = updateCondition.And(dexp.Equal(dexp.Name(t.fields.versionName), dexp.Value(defaultOpts.version)))
+	}
+
+	updateCondition = andConditions(updateCondition, defaultOpts.condition)
+
+	expr, err := dexp.NewBuilder().WithUpdate(update).WithCondition(updateCondition).Build()
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to build update expression: %w", err)
+	}
+
+	result, err := t.doUpdate(ctx, partitionKey, sortKey, expr, types.ReturnValueAllNew, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var version int64
+	if attr, ok := result.Attributes[t.fields.versionName]; ok {
+		err := attributevalue.Unmarshal(attr, &version)
+		if err != nil {
+			return nil, fmt.Errorf("dynastorev2: failed to extract version attribute: %w", err)
+		}
+	}
+
+	return &OperationResult{
+		Version:          version,
+		ConsumedCapacity: result.ConsumedCapacity,
+	}, nil
+}
+
+func (t *Store[P, S, V]) addFieldUpdates(update dexp.UpdateBuilder, updates []FieldUpdate) (dexp.UpdateBuilder, error) {
+	for _, fu := range updates {
+		err := t.checkFieldPath(fu.path)
+		if err != nil {
+			return update, err
+		}
+
+		name := dexp.Name(fu.path)
+
+		switch fu.operation {
+		case fieldSet:
+			update = update.Set(name, dexp.Value(fu.value))
+		case fieldRemove:
+			update = update.Remove(name)
+		case fieldIncrement:
+			update = update.Set(name, dexp.Plus(dexp.IfNotExists(name, dexp.Value(0)), dexp.Value(fu.value)))
+		case fieldAppend:
+			update = update.Set(name, dexp.ListAppend(dexp.IfNotExists(name, dexp.Value([]any{})), dexp.Value(fu.value)))
+		case fieldPrepend:
+			update = update.Set(name, dexp.ListAppend(dexp.Value(fu.value), dexp.IfNotExists(name, dexp.Value([]any{}))))
+		case fieldAddToSet, fieldDeleteFromSet:
+			set := fu.value.(SetValue)
+			if set.size == 0 {
+				return update, fmt.Errorf("dynastorev2: set provided for %s is empty", fu.path)
+			}
+
+			if fu.operation == fieldAddToSet {
+				update = update.Add(name, dexp.Value(set.value))
+			} else {
+				update = update.Delete(name, dexp.Value(set.value))
+			}
+		default:
+			return update, fmt.Errorf("dynastorev2: unknown field update operation %d", fu.operation)
+		}
+	}
+
+	return update, nil
+}
+
+// checkFieldPath ensures the path refers to a field within the payload or an extra field, and not to the attributes
+// managed by the store
+func (t *Store[P, S, V]) checkFieldPath(path string) error {
+	top, nested, _ := strings.Cut(path, ".")
+	top, _, _ = strings.Cut(top, "[")
+
+	if top != t.fields.payloadName {
+		if t.isReservedField(top) {
+			return fmt.Errorf("%w: %s", ErrReservedField, path)
+		}
+
+		return nil
+	}
+
+	if nested == "" {
+		return fmt.Errorf("%w: %s", ErrReservedField, path)
+	}
+
+	if t.storeOptions.keyProvider != nil || t.storeOptions.blobStore != nil {
+		return ErrPayloadFieldUpdateUnsupported
+	}
+
+	return nil
+}
+
+// checkCondition returns an error if the condition refers to fields within the payload when it is encrypted or may be
+// offloaded, as the stored payload is ciphertext or a pointer to the blob so the condition would never be met
+func (t *Store[P, S, V]) checkCondition(condition dexp.ConditionBuilder) error {
+	if !condition.IsSet() || (t.storeOptions.keyProvider == nil && t.storeOptions.blobStore == nil) {
+		return nil
+	}
+
+	expr, err := dexp.NewBuilder().WithCondition(condition).Build()
+	if err != nil {
+		return fmt.Errorf("dynastorev2: failed to build condition expression: %w", err)
+	}
+
+	// a field within the payload is referenced by the placeholder of the payload followed by a nested path
+	for placeholder, name := range expr.Names() {
+		if name != t.fields.payloadName {
+			continue
+		}
+
+		if strings.Contains(*expr.Condition(), placeholder+".") || strings.Contains(*expr.Condition(), placeholder+"[") {
+			return ErrPayloadConditionUnsupported
+		}
+	}
+
+	return nil
+}
@@ -1,10 +1,116 @@
 package dynastorev2
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
 
 // StoreHooks is a container for callbacks that can instrument the datastore
 type StoreHooks[P Key, S Key, V any] struct {
 	// RequestBuilt will be invoked prior to dispatching the request to the AWS SDK
-	RequestBuilt     func(ctx context.Context, pk P, sk S, params any) context.Context
+	RequestBuilt func(ctx context.Context, pk P, sk S, params any) context.Context
+	// ResponseReceived will be invoked after the AWS SDK returns with the consumed capacity, which is nil if the call failed
 	ResponseReceived func(ctx context.Context, pk P, sk S, params any) context.Context
+	// Error will be invoked with the details of the operation if the AWS SDK returns an error, including failed conditions
+	Error func(ctx context.Context, details *OperationDetails, err error)
+	// OnRequest will be invoked with a description of the request prior to dispatching it to the AWS SDK
+	OnRequest func(ctx context.Context, event *RequestEvent) context.Context
+	// OnResponse will be invoked with a description of the response after the AWS SDK returns, including failed calls
+	OnResponse func(ctx context.Context, event *ResponseEvent)
+	// OnRetry will be invoked before waiting to retry a failed request using the retry policy of the store
+	OnRetry func(ctx context.Context, event *RetryEvent)
+}
+
+// sendWithHooks dispatches the request to DynamoDB using the provided SDK call once the rate limiter permits it,
+// invoking the store hooks around it and recording the capacity consumed in the account attached to the context
+func sendWithHooks[P Key, S Key, V any, In any, Out any](ctx context.Context, t *Store[P, S, V], partitionKey P, sortKey S, params *In, call func(context.Context, *In, ...func(*dynamodb.Options)) (*Out, error), attempt int) (*Out, error) {
+	hooks := t.storeOptions.storeHooks
+	if hooks == nil {
+		hooks = &StoreHooks[P, S, V]{}
+	}
+
+	account := CapacityAccountFromContext(ctx)
+	limiter := t.storeOptions.rateLimiter
+
+	var event *RequestEvent
+	if hooks.OnRequest != nil || hooks.OnResponse != nil || account != nil || limiter != nil {
+		event = newRequestEvent(ctx, t.tableName, params)
+		event.Attempt = attempt
+	}
+
+	if limiter != nil {
+		if err := limiter.wait(ctx, event); err != nil {
+			return nil, err
+		}
+	}
+
+	if hooks.RequestBuilt != nil {
+		ctx = hooks.RequestBuilt(ctx, partitionKey, sortKey, params)
+	}
+
+	if hooks.OnRequest != nil {
+		ctx = hooks.OnRequest(ctx, event)
+	}
+
+	start := time.Now()
+
+	out, err := call(ctx, params)
+
+	duration := time.Since(start)
+
+	// the error hook is invoked first so the response hook can complete any instrumentation of the call
+	if err != nil && hooks.Error != nil {
+		hooks.Error(ctx, OperationDetailsFromContext(ctx), err)
+	}
+
+	if hooks.ResponseReceived != nil {
+		var consumed any
+		if err == nil {
+			consumed = consumedCapacity(out)
+		}
+
+		hooks.ResponseReceived(ctx, partitionKey, sortKey, consumed)
+	}
+
+	if event != nil {
+		response := newResponseEvent(event, out, err, duration)
+
+		if account != nil {
+			account.add(response)
+		}
+
+		if limiter != nil {
+			limiter.observe(response)
+		}
+
+		if hooks.OnResponse != nil {
+			hooks.OnResponse(ctx, response)
+		}
+	}
+
+	return out, err
+}
+
+// consumedCapacity extracts the capacity consumed from the output of a call to the AWS SDK
+func consumedCapacity(out any) any {
+	switch res := out.(type) {
+	case *dynamodb.GetItemOutput:
+		return res.ConsumedCapacity
+	case *dynamodb.PutItemOutput:
+		return res.ConsumedCapacity
+	case *dynamodb.UpdateItemOutput:
+		return res.ConsumedCapacity
+	case *dynamodb.DeleteItemOutput:
+		return res.ConsumedCapacity
+	case *dynamodb.QueryOutput:
+		return res.ConsumedCapacity
+	case *dynamodb.ScanOutput:
+		return res.ConsumedCapacity
+	case *dynamodb.BatchWriteItemOutput:
+		return res.ConsumedCapacity
+	default:
+		return nil
+	}
 }
@@ -0,0 +1,175 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+func TestCreateWithBlobStore(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	dir := t.TempDir()
+
+	store := newStore(t, dynastorev2.WithBlobStore[string, string, []byte](dynastorev2.NewFileBlobStore(dir), 64))
+	part := mustRandKey(partKeyLen)
+
+	large := bytes.Repeat([]byte("a"), 1024)
+
+	op, err := store.Create(ctx, part, "sort1", large)
+	assert.NoError(err)
+	assert.Equal(int64(1), op.Version)
+
+	res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("test-table"),
+		Key: map[string]types.AttributeValue{
+			"id":   &types.AttributeValueMemberS{Value: part},
+			"name": &types.AttributeValueMemberS{Value: "sort1"},
+		},
+	})
+	assert.NoError(err)
+	assert.NotContains(res.Item, "payload")
+	assert.Contains(res.Item, "blob_key")
+	assert.Contains(res.Item, "blob_checksum")
+	assert.Len(blobFiles(t, dir), 1)
+
+	op, val, err := store.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal(large, val)
+	assert.Equal(int64(1), op.Version)
+
+	_, vals, err := store.ListBySortKeyPrefix(ctx, part, "sort")
+	assert.NoError(err)
+	assert.Equal([][]byte{large}, vals)
+
+	// replacing the payload with a small value stores it in the item and removes the old blob
+	op, err = store.Update(ctx, part, "sort1", []byte("small"))
+	assert.NoError(err)
+	assert.Equal(int64(2), op.Version)
+	assert.Empty(blobFiles(t, dir))
+
+	_, val, err = store.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal([]byte("small"), val)
+
+	op, err = store.Update(ctx, part, "sort1", large)
+	assert.NoError(err)
+	assert.Equal(int64(3), op.Version)
+	assert.Len(blobFiles(t, dir), 1)
+
+	err = store.Delete(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Empty(blobFiles(t, dir))
+}
+
+func TestCreateWithBlobStoreAndEncryption(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	kp, err := dynastorev2.NewStaticKeyProvider("key1", map[string][]byte{
+		"key1": []byte("0123456789abcdef0123456789abcdef"),
+	})
+	assert.NoError(err)
+
+	dir := t.TempDir()
+
+	store := newStore(t,
+		dynastorev2.WithBlobStore[string, string, []byte](dynastorev2.NewFileBlobStore(dir), 64),
+		dynastorev2.WithEncryption[string, string, []byte](kp),
+	)
+	part := mustRandKey(partKeyLen)
+
+	large := bytes.Repeat([]byte("secret"), 256)
+
+	_, err = store.Create(ctx, part, "sort1", large)
+	assert.NoError(err)
+
+	files := blobFiles(t, dir)
+	assert.Len(files, 1)
+
+	data, err := os.ReadFile(files[0])
+	assert.NoError(err)
+	assert.NotContains(string(data), "secret")
+
+	_, val, err := store.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal(large, val)
+
+	// tampering with the blob must be detected using the checksum
+	err = os.WriteFile(files[0], []byte(`{"B":"AAAA"}`), 0o600)
+	assert.NoError(err)
+
+	_, _, err = store.Get(ctx, part, "sort1")
+	assert.ErrorIs(err, dynastorev2.ErrBlobChecksumMismatch)
+}
+
+func TestUpdateWithoutBlobStore(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	dir := t.TempDir()
+
+	store := newStore(t, dynastorev2.WithBlobStore[string, string, []byte](dynastorev2.NewFileBlobStore(dir), 64))
+	inlineStore := newStore[string, string, []byte](t)
+	part := mustRandKey(partKeyLen)
+
+	large := bytes.Repeat([]byte("a"), 1024)
+
+	_, err := store.Create(ctx, part, "sort1", large)
+	assert.NoError(err)
+
+	// writing without a blob store removes the pointer so the inline payload is read rather than the stale blob
+	_, err = inlineStore.Update(ctx, part, "sort1", large)
+	assert.NoError(err)
+
+	res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("test-table"),
+		Key: map[string]types.AttributeValue{
+			"id":   &types.AttributeValueMemberS{Value: part},
+			"name": &types.AttributeValueMemberS{Value: "sort1"},
+		},
+	})
+	assert.NoError(err)
+	assert.Contains(res.Item, "payload")
+	assert.NotContains(res.Item, "blob_key")
+	assert.NotContains(res.Item, "blob_checksum")
+
+	_, val, err := inlineStore.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal(large, val)
+
+	_, err = inlineStore.Update(ctx, part, "sort1", []byte("small"))
+	assert.NoError(err)
+
+	_, val, err = store.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal([]byte("small"), val)
+}
+
+func blobFiles(t *testing.T, dir string) []string {
+	var files []string
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	return files
+}
@@ -0,0 +1,288 @@
+package integration
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+func TestCache(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	cache := dynastorev2.NewLRUCache(100, time.Minute)
+
+	store := newStore(t, dynastorev2.WithCache[string, string, []byte](cache))
+	uncached := newStore[string, string, []byte](t)
+	part := mustRandKey(partKeyLen)
+
+	res, err := store.Create(ctx, part, "sort1", []byte("one"))
+	assert.NoError(err)
+	assert.Equal(1, cache.Len())
+
+	// served from the cache populated by the create
+	res, val, err := store.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal([]byte("one"), val)
+	assert.Equal(int64(1), res.Version)
+	assert.Nil(res.ConsumedCapacity)
+
+	res, err = store.Update(ctx, part, "sort1", []byte("two"))
+	assert.NoError(err)
+
+	_, record, err := store.GetRecord(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal([]byte("two"), record.Value)
+	assert.Equal(res.Version, record.Version)
+
+	// changes made outside the store are only seen by consistent reads until the entry expires
+	_, err = uncached.Update(ctx, part, "sort1", []byte("three"))
+	assert.NoError(err)
+
+	_, val, err = store.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal([]byte("two"), val)
+
+	res, val, err = store.Get(ctx, part, "sort1", store.ReadWithConsistentRead(true))
+	assert.NoError(err)
+	assert.Equal([]byte("three"), val)
+	assert.NotNil(res.ConsumedCapacity)
+
+	// the delete leaves an entry without data which isn't served
+	err = store.Delete(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal(1, cache.Len())
+
+	_, _, err = store.Get(ctx, part, "sort1")
+	assert.ErrorIs(err, dynastorev2.ErrKeyNotExists)
+}
+
+func TestCacheReadThrough(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	cache := dynastorev2.NewLRUCache(100, 0)
+
+	store := newStore(t, dynastorev2.WithCache[string, string, []byte](cache))
+	uncached := newStore[string, string, []byte](t)
+	part := mustRandKey(partKeyLen)
+
+	_, err := uncached.Create(ctx, part, "sort1", []byte("data"), uncached.WriteWithTTL(time.Second))
+	assert.NoError(err)
+
+	res, _, err := store.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.NotNil(res.ConsumedCapacity)
+	assert.Equal(1, cache.Len())
+
+	res, _, err = store.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Nil(res.ConsumedCapacity)
+
+	// the entry expires with the record so it is read from the table again
+	time.Sleep(1100 * time.Millisecond)
+
+	res, _, err = store.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.NotNil(res.ConsumedCapacity)
+}
+
+func TestCacheIncrement(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	cache := dynastorev2.NewLRUCache(100, 0)
+
+	store := newStore(t, dynastorev2.WithCache[string, string, []byte](cache))
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"))
+	assert.NoError(err)
+
+	// counters don't modify the version so the entry is removed rather than superseded
+	_, _, err = store.Increment(ctx, part, "sort1", "requests", 2)
+	assert.NoError(err)
+	assert.Zero(cache.Len())
+
+	res, record, err := store.GetRecord(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.NotNil(res.ConsumedCapacity)
+	assert.Equal(float64(2), record.Fields["requests"])
+}
+
+// countingKeyProvider counts the data keys unwrapped to decrypt payloads
+type countingKeyProvider struct {
+	dynastorev2.KeyProvider
+	unwrapped atomic.Int64
+}
+
+func (kp *countingKeyProvider) UnwrapKey(ctx context.Context, keyID string, wrappedKey []byte) ([]byte, error) {
+	kp.unwrapped.Add(1)
+	return kp.KeyProvider.UnwrapKey(ctx, keyID, wrappedKey)
+}
+
+func TestCacheWithEncryption(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	static, err := dynastorev2.NewStaticKeyProvider("key1", map[string][]byte{
+		"key1": []byte("0123456789abcdef0123456789abcdef"),
+	})
+	assert.NoError(err)
+
+	kp := &countingKeyProvider{KeyProvider: static}
+	cache := dynastorev2.NewLRUCache(100, 0)
+
+	store := newStore(t,
+		dynastorev2.WithEncryption[string, string, []byte](kp),
+		dynastorev2.WithCache[string, string, []byte](cache),
+	)
+	part := mustRandKey(partKeyLen)
+
+	_, err = store.Create(ctx, part, "sort1", []byte("one"))
+	assert.NoError(err)
+
+	// the decoded record is cached by the write so reads don't unwrap the data key
+	for range 3 {
+		res, val, err := store.Get(ctx, part, "sort1")
+		assert.NoError(err)
+		assert.Equal([]byte("one"), val)
+		assert.Nil(res.ConsumedCapacity)
+	}
+
+	assert.Zero(kp.unwrapped.Load())
+
+	// records written by other processes are decrypted once when read then served from the cache
+	uncached := newStore(t, dynastorev2.WithEncryption[string, string, []byte](static))
+
+	_, err = uncached.Create(ctx, part, "sort2", []byte("two"))
+	assert.NoError(err)
+
+	for range 3 {
+		_, val, err := store.Get(ctx, part, "sort2")
+		assert.NoError(err)
+		assert.Equal([]byte("two"), val)
+	}
+
+	assert.Equal(int64(1), kp.unwrapped.Load())
+}
+
+// recordingCache records the entries set in the cache so they can be replayed
+type recordingCache struct {
+	*dynastorev2.LRUCache
+	entries map[string]dynastorev2.CacheEntry
+}
+
+func (c *recordingCache) Set(ctx context.Context, key string, entry dynastorev2.CacheEntry) error {
+	c.entries[key] = entry
+	return c.LRUCache.Set(ctx, key, entry)
+}
+
+func TestCacheDeleteRejectsStaleEntries(t *testing.T) {
+	ctx := context.Background()
+
+	cache := &recordingCache{LRUCache: dynastorev2.NewLRUCache(100, 0), entries: make(map[string]dynastorev2.CacheEntry)}
+
+	for _, name := range []string{"hard", "soft"} {
+		t.Run(name, func(t *testing.T) {
+			assert := require.New(t)
+
+			options := []dynastorev2.StoreOption[string, string, []byte]{dynastorev2.WithCache[string, string, []byte](cache)}
+			if name == "soft" {
+				options = append(options, dynastorev2.WithSoftDelete[string, string, []byte](time.Hour))
+			}
+
+			store := newStore(t, options...)
+			part := mustRandKey(partKeyLen)
+
+			_, err := store.Create(ctx, part, "sort1", []byte("one"))
+			assert.NoError(err)
+
+			var (
+				key   string
+				entry dynastorev2.CacheEntry
+			)
+
+			for k, v := range cache.entries {
+				if strings.Contains(k, part) {
+					key, entry = k, v
+				}
+			}
+
+			assert.NotEmpty(entry.Data)
+
+			err = store.Delete(ctx, part, "sort1")
+			assert.NoError(err)
+
+			// a slow read of the record before it was deleted is rejected by the entry left by the delete
+			assert.NoError(cache.Set(ctx, key, entry))
+
+			cached, ok, err := cache.Get(ctx, key)
+			assert.NoError(err)
+			assert.True(ok)
+			assert.Empty(cached.Data)
+			assert.Equal(int64(2), cached.Version)
+
+			_, _, err = store.Get(ctx, part, "sort1")
+			assert.ErrorIs(err, dynastorev2.ErrKeyNotExists)
+
+			// records created again start from version one and replace the entry left by the delete
+			_, err = store.Create(ctx, part, "sort1", []byte("two"))
+			assert.NoError(err)
+
+			res, val, err := store.Get(ctx, part, "sort1")
+			assert.NoError(err)
+			assert.Equal([]byte("two"), val)
+			assert.Equal(int64(1), res.Version)
+			assert.Nil(res.ConsumedCapacity)
+		})
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	cache := dynastorev2.NewLRUCache(2, 0)
+
+	assert.NoError(cache.Set(ctx, "a", dynastorev2.CacheEntry{Version: 2, Data: []byte("a2")}))
+
+	// an older version doesn't replace a newer one
+	assert.NoError(cache.Set(ctx, "a", dynastorev2.CacheEntry{Version: 1, Data: []byte("a1")}))
+
+	entry, ok, err := cache.Get(ctx, "a")
+	assert.NoError(err)
+	assert.True(ok)
+	assert.Equal([]byte("a2"), entry.Data)
+
+	// entries without data hold the version to reject older entries
+	assert.NoError(cache.Set(ctx, "a", dynastorev2.CacheEntry{Version: 3}))
+	assert.NoError(cache.Set(ctx, "a", dynastorev2.CacheEntry{Version: 2, Data: []byte("a2")}))
+
+	entry, ok, err = cache.Get(ctx, "a")
+	assert.NoError(err)
+	assert.True(ok)
+	assert.Empty(entry.Data)
+	assert.Equal(int64(3), entry.Version)
+
+	// b is evicted as a was used more recently
+	assert.NoError(cache.Set(ctx, "b", dynastorev2.CacheEntry{Version: 1}))
+	_, _, _ = cache.Get(ctx, "a")
+	assert.NoError(cache.Set(ctx, "c", dynastorev2.CacheEntry{Version: 1}))
+
+	_, ok, _ = cache.Get(ctx, "b")
+	assert.False(ok)
+
+	_, ok, _ = cache.Get(ctx, "a")
+	assert.True(ok)
+
+	assert.NoError(cache.Set(ctx, "d", dynastorev2.CacheEntry{Version: 1, Expires: time.Now().Add(-time.Second)}))
+
+	_, ok, _ = cache.Get(ctx, "d")
+	assert.False(ok)
+}
@@ -0,0 +1,93 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+func TestCapacityAccount(t *testing.T) {
+	assert := require.New(t)
+
+	store := newStore[string, string, []byte](t)
+	part := mustRandKey(partKeyLen)
+	pk1 := fmt.Sprintf("%s#%s", part, "new")
+
+	ctx, account := dynastorev2.WithCapacityAccount(context.Background())
+
+	for i := 0; i < 3; i++ {
+		_, err := store.Create(ctx, part, fmt.Sprintf("sort%d", i), []byte("data"), store.WriteWithExtraFields(
+			map[string]any{
+				"pk1": pk1,
+				"sk1": fmt.Sprintf("2025010%d", i),
+			},
+		))
+		assert.NoError(err)
+	}
+
+	_, _, err := store.Get(ctx, part, "sort1")
+	assert.NoError(err)
+
+	_, results, err := store.ListBySortKeyPrefix(ctx, pk1, "2025", store.ReadWithIndex("idx_global_1", "pk1", "sk1"))
+	assert.NoError(err)
+	assert.Len(results, 3)
+
+	total := account.Total()
+	assert.Greater(total.WriteUnits, 0.0)
+	assert.Greater(total.ReadUnits, 0.0)
+
+	tables := account.Tables()
+	assert.Contains(tables, "test-table")
+	assert.Equal(total, tables["test-table"].Capacity)
+	assert.Greater(tables["test-table"].Indexes["idx_global_1"].ReadUnits, 0.0)
+
+	// operations without the account aren't recorded
+	_, _, err = store.Get(context.Background(), part, "sort1")
+	assert.NoError(err)
+	assert.Equal(total, account.Total())
+}
+
+func TestReturnConsumedCapacityIndexes(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore(t, dynastorev2.WithReturnConsumedCapacity[string, string, []byte](types.ReturnConsumedCapacityIndexes))
+	part := mustRandKey(partKeyLen)
+	pk1 := fmt.Sprintf("%s#%s", part, "new")
+
+	res, err := store.Create(ctx, part, "sort1", []byte("data"), store.WriteWithExtraFields(
+		map[string]any{
+			"pk1": pk1,
+			"sk1": "20250101",
+		},
+	))
+	assert.NoError(err)
+	assert.Contains(res.IndexCapacityUnits(), "idx_global_1")
+
+	res, results, err := store.ListBySortKeyPrefix(ctx, pk1, "2025", store.ReadWithIndex("idx_global_1", "pk1", "sk1"))
+	assert.NoError(err)
+	assert.Len(results, 1)
+	assert.Greater(res.IndexCapacityUnits()["idx_global_1"], 0.0)
+}
+
+func TestReturnConsumedCapacityNone(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore(t, dynastorev2.WithReturnConsumedCapacity[string, string, []byte](types.ReturnConsumedCapacityNone))
+	part := mustRandKey(partKeyLen)
+
+	res, err := store.Create(ctx, part, "sort1", []byte("data"))
+	assert.NoError(err)
+	assert.Nil(res.ConsumedCapacity)
+
+	res, count, err := store.DeleteByPartition(ctx, part)
+	assert.NoError(err)
+	assert.Equal(1, count)
+	assert.Nil(res.ConsumedCapacity)
+	assert.Nil(res.IndexCapacityUnits())
+}
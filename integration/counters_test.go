@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+func TestIncrement(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, []byte](t)
+	part := mustRandKey(partKeyLen)
+
+	_, _, err := store.Increment(ctx, part, "usage", "requests", 1)
+	assert.Error(err)
+
+	_, count, err := store.Increment(ctx, part, "usage", "requests", 1, store.WriteWithCreateIfMissing(true), store.WriteWithTTL(time.Minute))
+	assert.NoError(err)
+	assert.Equal(int64(1), count)
+
+	_, count, err = store.Increment(ctx, part, "usage", "requests", 5)
+	assert.NoError(err)
+	assert.Equal(int64(6), count)
+
+	_, count, err = store.Increment(ctx, part, "usage", "requests", -2)
+	assert.NoError(err)
+	assert.Equal(int64(4), count)
+
+	_, _, err = store.Increment(ctx, part, "usage", "version", 1)
+	assert.ErrorIs(err, dynastorev2.ErrReservedField)
+}
+
+func TestIncrementMany(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, []byte](t)
+	part := mustRandKey(partKeyLen)
+
+	op, err := store.Create(ctx, part, "usage", []byte("data"))
+	assert.NoError(err)
+	assert.Equal(int64(1), op.Version)
+
+	_, counters, err := store.IncrementMany(ctx, part, "usage", map[string]int64{"reads": 10, "writes": 2})
+	assert.NoError(err)
+	assert.Equal(map[string]int64{"reads": 10, "writes": 2}, counters)
+
+	_, counters, err = store.IncrementMany(ctx, part, "usage", map[string]int64{"reads": 5, "writes": 1})
+	assert.NoError(err)
+	assert.Equal(map[string]int64{"reads": 15, "writes": 3}, counters)
+
+	// counters don't modify the version of the record
+	op, val, err := store.Get(ctx, part, "usage")
+	assert.NoError(err)
+	assert.Equal([]byte("data"), val)
+	assert.Equal(int64(1), op.Version)
+}
@@ -6,7 +6,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	dexp "github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/stretchr/testify/require"
 	"github.com/wolfeidau/dynastorev2"
 )
@@ -238,6 +241,49 @@ func TestUpdateWithFieldsReservedError(t *testing.T) {
 	assert.ErrorAs(err, &dynastorev2.ErrReservedField)
 }
 
+func TestUpdateWithRemoveFieldsAndTTLCleared(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, []byte](t)
+	part := mustRandKey(partKeyLen)
+
+	pk1 := fmt.Sprintf("%s#%s", part, "pending")
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"), store.WriteWithTTL(10*time.Second), store.WriteWithExtraFields(
+		map[string]any{
+			"pk1": pk1,
+			"sk1": "20250101",
+		},
+	))
+	assert.NoError(err)
+
+	_, results, err := store.ListBySortKeyPrefix(ctx, pk1, "2025", store.ReadWithIndex("idx_global_1", "pk1", "sk1"))
+	assert.NoError(err)
+	assert.Len(results, 1)
+
+	_, err = store.Update(ctx, part, "sort1", []byte("data"), store.WriteWithRemoveFields("pk1", "sk1"), store.WriteWithTTLCleared())
+	assert.NoError(err)
+
+	_, results, err = store.ListBySortKeyPrefix(ctx, pk1, "2025", store.ReadWithIndex("idx_global_1", "pk1", "sk1"))
+	assert.NoError(err)
+	assert.Empty(results)
+
+	res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("test-table"),
+		Key: map[string]types.AttributeValue{
+			"id":   &types.AttributeValueMemberS{Value: part},
+			"name": &types.AttributeValueMemberS{Value: "sort1"},
+		},
+	})
+	assert.NoError(err)
+	assert.NotContains(res.Item, "expires")
+	assert.NotContains(res.Item, "pk1")
+
+	_, err = store.Update(ctx, part, "sort1", []byte("data"), store.WriteWithRemoveFields("version"))
+	assert.ErrorIs(err, dynastorev2.ErrReservedField)
+}
+
 func TestUpdateWithVersion(t *testing.T) {
 	assert := require.New(t)
 
@@ -254,6 +300,99 @@ func TestUpdateWithVersion(t *testing.T) {
 	assert.Error(err)
 }
 
+func TestUpdateWithCondition(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, Ticket](t)
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "ticket1", Ticket{Title: "broken build", Status: "OPEN"})
+	assert.NoError(err)
+
+	pending := dexp.Name(store.PayloadField("Status")).Equal(dexp.Value("PENDING"))
+
+	_, err = store.Update(ctx, part, "ticket1", Ticket{Title: "broken build", Status: "CLOSED"}, store.WriteWithCondition(pending))
+	var oe *types.ConditionalCheckFailedException
+	assert.ErrorAs(err, &oe)
+
+	_, err = store.Update(ctx, part, "ticket1", Ticket{Title: "broken build", Status: "PENDING"})
+	assert.NoError(err)
+
+	op, err := store.Update(ctx, part, "ticket1", Ticket{Title: "broken build", Status: "CLOSED"}, store.WriteWithCondition(pending), store.WriteWithVersion(2))
+	assert.NoError(err)
+	assert.Equal(int64(3), op.Version)
+
+	// the condition is combined with the create constraint
+	_, err = store.Create(ctx, part, "ticket2", Ticket{Title: "flaky test"}, store.WriteWithCondition(pending))
+	assert.ErrorAs(err, &oe)
+}
+
+func TestDeleteWithCondition(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, []byte](t)
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"), store.WriteWithExtraFields(map[string]any{"owner": "mark"}))
+	assert.NoError(err)
+
+	err = store.Delete(ctx, part, "sort1", store.DeleteWithCondition(dexp.Name("owner").Equal(dexp.Value("jane"))))
+	assert.ErrorIs(err, dynastorev2.ErrDeleteFailedConditionCheck)
+
+	err = store.Delete(ctx, part, "sort2", store.DeleteWithCondition(dexp.Name("owner").Equal(dexp.Value("mark"))))
+	assert.ErrorIs(err, dynastorev2.ErrDeleteFailedKeyNotExists)
+
+	err = store.Delete(ctx, part, "sort1", store.DeleteWithCondition(dexp.Name("owner").Equal(dexp.Value("mark"))))
+	assert.NoError(err)
+}
+
+func TestDeleteWithVersion(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, []byte](t)
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"))
+	assert.NoError(err)
+
+	_, err = store.Update(ctx, part, "sort1", []byte("data2"))
+	assert.NoError(err)
+
+	err = store.Delete(ctx, part, "sort1", store.DeleteWithVersion(1))
+	assert.ErrorIs(err, dynastorev2.ErrDeleteFailedConditionCheck)
+
+	err = store.Delete(ctx, part, "sort2", store.DeleteWithVersion(1))
+	assert.ErrorIs(err, dynastorev2.ErrDeleteFailedKeyNotExists)
+
+	err = store.Delete(ctx, part, "sort1", store.DeleteWithVersion(2))
+	assert.NoError(err)
+}
+
+func TestDeleteAndReturn(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, []byte](t)
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"))
+	assert.NoError(err)
+
+	op, val, err := store.DeleteAndReturn(ctx, part, "sort1", store.DeleteWithVersion(1))
+	assert.NoError(err)
+	assert.Equal([]byte("data"), val)
+	assert.Equal(int64(1), op.Version)
+
+	_, _, err = store.DeleteAndReturn(ctx, part, "sort1")
+	assert.ErrorIs(err, dynastorev2.ErrDeleteFailedKeyNotExists)
+
+	_, _, err = store.DeleteAndReturn(ctx, part, "sort1", store.DeleteWithCheck(false))
+	assert.ErrorIs(err, dynastorev2.ErrDeleteFailedKeyNotExists)
+}
+
 func TestDelete(t *testing.T) {
 	assert := require.New(t)
 
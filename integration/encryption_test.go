@@ -0,0 +1,177 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	dexp "github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+func TestCreateWithEncryption(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	kp, err := dynastorev2.NewStaticKeyProvider("key1", map[string][]byte{
+		"key1": []byte("0123456789abcdef0123456789abcdef"),
+	})
+	assert.NoError(err)
+
+	store := newStore(t, dynastorev2.WithEncryption[string, string, Customer](kp))
+	part := mustRandKey(partKeyLen)
+
+	cust := Customer{ID: mustRandKey(partKeyLen), Name: "test", Created: time.Now().UTC().Round(time.Millisecond)}
+
+	_, err = store.Create(ctx, part, cust.ID, cust)
+	assert.NoError(err)
+
+	op, val, err := store.Get(ctx, part, cust.ID)
+	assert.NoError(err)
+	assert.Equal(cust, val)
+	assert.Equal(int64(1), op.Version)
+
+	res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("test-table"),
+		Key: map[string]types.AttributeValue{
+			"id":   &types.AttributeValueMemberS{Value: part},
+			"name": &types.AttributeValueMemberS{Value: cust.ID},
+		},
+	})
+	assert.NoError(err)
+	assert.IsType(&types.AttributeValueMemberB{}, res.Item["payload"])
+	assert.Equal(&types.AttributeValueMemberS{Value: "key1"}, res.Item["key_id"])
+	assert.NotContains(string(res.Item["payload"].(*types.AttributeValueMemberB).Value), cust.Name)
+
+	_, vals, err := store.ListBySortKeyPrefix(ctx, part, cust.ID)
+	assert.NoError(err)
+	assert.Equal([]Customer{cust}, vals)
+
+	// the payload is bound to the keys so copying it to another item must fail to decrypt
+	res.Item["name"] = &types.AttributeValueMemberS{Value: fmt.Sprintf("%s-copy", cust.ID)}
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String("test-table"),
+		Item:      res.Item,
+	})
+	assert.NoError(err)
+
+	_, _, err = store.Get(ctx, part, fmt.Sprintf("%s-copy", cust.ID))
+	assert.Error(err)
+}
+
+func TestUpdateWithEncryptionKeyRotation(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	keys := map[string][]byte{
+		"key1": []byte("0123456789abcdef0123456789abcdef"),
+		"key2": []byte("fedcba9876543210fedcba9876543210"),
+	}
+
+	kp1, err := dynastorev2.NewStaticKeyProvider("key1", keys)
+	assert.NoError(err)
+
+	kp2, err := dynastorev2.NewStaticKeyProvider("key2", keys)
+	assert.NoError(err)
+
+	store1 := newStore(t, dynastorev2.WithEncryption[string, string, []byte](kp1))
+	store2 := newStore(t, dynastorev2.WithEncryption[string, string, []byte](kp2))
+	part := mustRandKey(partKeyLen)
+
+	_, err = store1.Create(ctx, part, "sort1", []byte("data"))
+	assert.NoError(err)
+
+	_, val, err := store2.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal([]byte("data"), val)
+
+	_, err = store2.Update(ctx, part, "sort1", []byte("data2"))
+	assert.NoError(err)
+
+	_, val, err = store1.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal([]byte("data2"), val)
+
+	plainStore := newStore[string, string, []byte](t)
+
+	_, _, err = plainStore.Get(ctx, part, "sort1")
+	assert.ErrorIs(err, dynastorev2.ErrKeyProviderMissing)
+}
+
+func TestUpdateWithEncryptionDisabled(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	kp, err := dynastorev2.NewStaticKeyProvider("key1", map[string][]byte{
+		"key1": []byte("0123456789abcdef0123456789abcdef"),
+	})
+	assert.NoError(err)
+
+	store := newStore(t, dynastorev2.WithEncryption[string, string, []byte](kp))
+	plainStore := newStore[string, string, []byte](t)
+	part := mustRandKey(partKeyLen)
+
+	_, err = store.Create(ctx, part, "sort1", []byte("data"))
+	assert.NoError(err)
+
+	// writing without encryption removes the key attributes so the plaintext payload isn't decrypted
+	_, err = plainStore.Update(ctx, part, "sort1", []byte("data2"))
+	assert.NoError(err)
+
+	res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("test-table"),
+		Key: map[string]types.AttributeValue{
+			"id":   &types.AttributeValueMemberS{Value: part},
+			"name": &types.AttributeValueMemberS{Value: "sort1"},
+		},
+	})
+	assert.NoError(err)
+	assert.NotContains(res.Item, "key_id")
+	assert.NotContains(res.Item, "data_key")
+
+	_, val, err := plainStore.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal([]byte("data2"), val)
+
+	_, val, err = store.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal([]byte("data2"), val)
+}
+
+func TestConditionWithEncryption(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	kp, err := dynastorev2.NewStaticKeyProvider("key1", map[string][]byte{
+		"key1": []byte("0123456789abcdef0123456789abcdef"),
+	})
+	assert.NoError(err)
+
+	store := newStore(t, dynastorev2.WithEncryption[string, string, Ticket](kp))
+	part := mustRandKey(partKeyLen)
+
+	_, err = store.Create(ctx, part, "ticket1", Ticket{Title: "broken build", Status: "open"}, store.WriteWithExtraFields(
+		map[string]any{"assignee": "mark"},
+	))
+	assert.NoError(err)
+
+	// the encrypted payload can't be matched by a condition so these are rejected rather than failing the condition
+	payloadCond := dexp.Equal(dexp.Name(store.PayloadField("status")), dexp.Value("open"))
+
+	_, err = store.Update(ctx, part, "ticket1", Ticket{Title: "broken build", Status: "closed"}, store.WriteWithCondition(payloadCond))
+	assert.ErrorIs(err, dynastorev2.ErrPayloadConditionUnsupported)
+
+	err = store.Delete(ctx, part, "ticket1", store.DeleteWithCondition(payloadCond))
+	assert.ErrorIs(err, dynastorev2.ErrPayloadConditionUnsupported)
+
+	// conditions on the extra fields are unaffected
+	op, err := store.Update(ctx, part, "ticket1", Ticket{Title: "broken build", Status: "closed"},
+		store.WriteWithCondition(dexp.Equal(dexp.Name("assignee"), dexp.Value("mark"))))
+	assert.NoError(err)
+	assert.Equal(int64(2), op.Version)
+}
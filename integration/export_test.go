@@ -0,0 +1,228 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+func TestExportImport(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, Ticket](t)
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "ticket1", Ticket{Title: "broken build", Status: "open"}, store.WriteWithExtraFields(map[string]any{
+		"assignee": "mark",
+	}), store.WriteWithTTL(time.Hour))
+	assert.NoError(err)
+
+	_, err = store.Update(ctx, part, "ticket1", Ticket{Title: "broken build", Status: "closed"})
+	assert.NoError(err)
+
+	_, err = store.Create(ctx, part, "ticket2", Ticket{Title: "slow tests", Status: "open"})
+	assert.NoError(err)
+
+	_, err = store.Create(ctx, part, "other1", Ticket{Title: "flaky deploy", Status: "open"})
+	assert.NoError(err)
+
+	buf := new(bytes.Buffer)
+
+	_, count, err := store.ExportBySortKeyPrefix(ctx, buf, part, "ticket")
+	assert.NoError(err)
+	assert.Equal(2, count)
+	assert.Len(strings.Split(strings.TrimSpace(buf.String()), "\n"), 2)
+
+	_, count, err = store.ExportPartition(ctx, new(bytes.Buffer), part)
+	assert.NoError(err)
+	assert.Equal(3, count)
+
+	_, _, err = store.DeleteByPartition(ctx, part)
+	assert.NoError(err)
+
+	_, count, err = store.Import(ctx, buf)
+	assert.NoError(err)
+	assert.Equal(2, count)
+
+	op, val, err := store.Get(ctx, part, "ticket1")
+	assert.NoError(err)
+	assert.Equal(Ticket{Title: "broken build", Status: "closed"}, val)
+	assert.Equal(int64(2), op.Version)
+
+	res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("test-table"),
+		Key: map[string]types.AttributeValue{
+			"id":   &types.AttributeValueMemberS{Value: part},
+			"name": &types.AttributeValueMemberS{Value: "ticket1"},
+		},
+	})
+	assert.NoError(err)
+	assert.Equal(&types.AttributeValueMemberS{Value: "mark"}, res.Item["assignee"])
+	assert.Contains(res.Item, "expires")
+
+	_, _, err = store.Get(ctx, part, "other1")
+	assert.Error(err)
+
+	// records are validated before they are imported
+	_, _, err = store.Import(ctx, strings.NewReader(`{"partition_key":"`+part+`","sort_key":"ticket3","version":1,"value":{"Status":"open"}}`))
+	assert.Error(err)
+
+	_, _, err = store.Import(ctx, strings.NewReader("not json\n"))
+	assert.Error(err)
+}
+
+func TestExportImportTypedFields(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, Ticket](t)
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "ticket1", Ticket{Title: "broken build", Status: "open"}, store.WriteWithExtraFields(map[string]any{
+		"checksum": []byte{0x00, 0xff, 0x10},
+		"sequence": uint64(1<<53 + 1),
+	}))
+	assert.NoError(err)
+
+	_, err = store.UpdateFields(ctx, part, "ticket1", []dynastorev2.FieldUpdate{
+		dynastorev2.AddToSet("labels", dynastorev2.StringSet("ci", "urgent")),
+	})
+	assert.NoError(err)
+
+	getItem := func() map[string]types.AttributeValue {
+		res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName: aws.String("test-table"),
+			Key: map[string]types.AttributeValue{
+				"id":   &types.AttributeValueMemberS{Value: part},
+				"name": &types.AttributeValueMemberS{Value: "ticket1"},
+			},
+		})
+		assert.NoError(err)
+
+		return res.Item
+	}
+
+	buf := new(bytes.Buffer)
+
+	_, _, err = store.ExportPartition(ctx, buf, part)
+	assert.NoError(err)
+
+	_, _, err = store.DeleteByPartition(ctx, part)
+	assert.NoError(err)
+
+	_, count, err := store.Import(ctx, buf)
+	assert.NoError(err)
+	assert.Equal(1, count)
+
+	// the extra fields are imported with the same types
+	item := getItem()
+	assert.IsType(&types.AttributeValueMemberSS{}, item["labels"])
+	assert.ElementsMatch([]string{"ci", "urgent"}, item["labels"].(*types.AttributeValueMemberSS).Value)
+	assert.Equal(&types.AttributeValueMemberB{Value: []byte{0x00, 0xff, 0x10}}, item["checksum"])
+	assert.Equal(&types.AttributeValueMemberN{Value: "9007199254740993"}, item["sequence"])
+
+	// so set operations continue to work
+	_, err = store.UpdateFields(ctx, part, "ticket1", []dynastorev2.FieldUpdate{
+		dynastorev2.DeleteFromSet("labels", dynastorev2.StringSet("urgent")),
+	})
+	assert.NoError(err)
+
+	assert.Equal(&types.AttributeValueMemberSS{Value: []string{"ci"}}, getItem()["labels"])
+}
+
+func TestImportDuplicateKeys(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, Ticket](t)
+	part := mustRandKey(partKeyLen)
+
+	// the same keys in one batch would be rejected by BatchWriteItem, so the last record read is retained
+	lines := strings.Join([]string{
+		`{"partition_key":"` + part + `","sort_key":"ticket1","version":1,"value":{"Title":"first","Status":"open"}}`,
+		`{"partition_key":"` + part + `","sort_key":"ticket2","version":1,"value":{"Title":"other","Status":"open"}}`,
+		`{"partition_key":"` + part + `","sort_key":"ticket1","version":2,"value":{"Title":"second","Status":"closed"}}`,
+	}, "\n")
+
+	_, count, err := store.Import(ctx, strings.NewReader(lines))
+	assert.NoError(err)
+	assert.Equal(3, count)
+
+	op, val, err := store.Get(ctx, part, "ticket1")
+	assert.NoError(err)
+	assert.Equal(Ticket{Title: "second", Status: "closed"}, val)
+	assert.Equal(int64(2), op.Version)
+
+	_, val, err = store.Get(ctx, part, "ticket2")
+	assert.NoError(err)
+	assert.Equal("other", val.Title)
+}
+
+func TestExportImportDeleted(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore(t, dynastorev2.WithSoftDelete[string, string, Ticket](time.Hour))
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "ticket1", Ticket{Title: "broken build", Status: "open"}, store.WriteWithTTL(24*time.Hour))
+	assert.NoError(err)
+
+	_, err = store.Create(ctx, part, "ticket2", Ticket{Title: "slow tests", Status: "open"})
+	assert.NoError(err)
+
+	err = store.Delete(ctx, part, "ticket1")
+	assert.NoError(err)
+
+	buf := new(bytes.Buffer)
+
+	_, count, err := store.ExportPartition(ctx, buf, part, store.ReadWithDeleted(true))
+	assert.NoError(err)
+	assert.Equal(2, count)
+	assert.Contains(buf.String(), `"deleted":`)
+
+	_, _, err = store.DeleteByPartition(ctx, part)
+	assert.NoError(err)
+
+	_, count, err = store.Import(ctx, buf)
+	assert.NoError(err)
+	assert.Equal(2, count)
+
+	// the tombstone is imported so the record remains deleted
+	_, _, err = store.Get(ctx, part, "ticket1")
+	assert.ErrorIs(err, dynastorev2.ErrKeyNotExists)
+
+	_, val, err := store.Get(ctx, part, "ticket2")
+	assert.NoError(err)
+	assert.Equal("slow tests", val.Title)
+
+	// the TTL saved in the tombstone is put back when it is restored
+	_, err = store.Restore(ctx, part, "ticket1")
+	assert.NoError(err)
+
+	res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("test-table"),
+		Key: map[string]types.AttributeValue{
+			"id":   &types.AttributeValueMemberS{Value: part},
+			"name": &types.AttributeValueMemberS{Value: "ticket1"},
+		},
+	})
+	assert.NoError(err)
+	assert.NotContains(res.Item, "deleted_at")
+
+	assert.IsType(&types.AttributeValueMemberN{}, res.Item["expires"])
+
+	expires, err := strconv.ParseInt(res.Item["expires"].(*types.AttributeValueMemberN).Value, 10, 64)
+	assert.NoError(err)
+	assert.Greater(time.Unix(expires, 0), time.Now().Add(23*time.Hour))
+}
@@ -0,0 +1,141 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+func TestUpdateFields(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, Ticket](t)
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "ticket1", Ticket{Title: "broken build", Status: "open"}, store.WriteWithExtraFields(map[string]any{
+		"assignee": "mark",
+	}))
+	assert.NoError(err)
+
+	op, err := store.UpdateFields(ctx, part, "ticket1", []dynastorev2.FieldUpdate{
+		dynastorev2.SetField(store.PayloadField("Status"), "closed"),
+		dynastorev2.IncrementField("comments", 2),
+		dynastorev2.AppendToField("labels", "ci"),
+	})
+	assert.NoError(err)
+	assert.Equal(int64(2), op.Version)
+
+	// a second writer modifying a different field doesn't clobber the first
+	op, err = store.UpdateFields(ctx, part, "ticket1", []dynastorev2.FieldUpdate{
+		dynastorev2.SetField(store.PayloadField("Title"), "broken release"),
+		dynastorev2.IncrementField("comments", 1),
+		dynastorev2.AppendToField("labels", "release"),
+		dynastorev2.RemoveField("assignee"),
+	}, store.WriteWithVersion(2))
+	assert.NoError(err)
+	assert.Equal(int64(3), op.Version)
+
+	op, val, err := store.Get(ctx, part, "ticket1")
+	assert.NoError(err)
+	assert.Equal(Ticket{Title: "broken release", Status: "closed"}, val)
+	assert.Equal(int64(3), op.Version)
+
+	res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("test-table"),
+		Key: map[string]types.AttributeValue{
+			"id":   &types.AttributeValueMemberS{Value: part},
+			"name": &types.AttributeValueMemberS{Value: "ticket1"},
+		},
+	})
+	assert.NoError(err)
+	assert.Equal(&types.AttributeValueMemberN{Value: "3"}, res.Item["comments"])
+	assert.Equal(&types.AttributeValueMemberL{Value: []types.AttributeValue{
+		&types.AttributeValueMemberS{Value: "ci"},
+		&types.AttributeValueMemberS{Value: "release"},
+	}}, res.Item["labels"])
+	assert.NotContains(res.Item, "assignee")
+
+	_, err = store.UpdateFields(ctx, part, "ticket1", []dynastorev2.FieldUpdate{
+		dynastorev2.SetField("version", 10),
+	})
+	assert.ErrorIs(err, dynastorev2.ErrReservedField)
+
+	_, err = store.UpdateFields(ctx, part, "ticket1", []dynastorev2.FieldUpdate{
+		dynastorev2.SetField(store.PayloadField("Status"), "open"),
+	}, store.WriteWithVersion(1))
+	assert.Error(err)
+
+	_, err = store.UpdateFields(ctx, part, "ticket2", []dynastorev2.FieldUpdate{
+		dynastorev2.SetField(store.PayloadField("Status"), "open"),
+	})
+	assert.Error(err)
+}
+
+func TestUpdateFieldsSetsAndLists(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, Ticket](t)
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "ticket1", Ticket{Title: "broken build", Status: "open"})
+	assert.NoError(err)
+
+	_, err = store.UpdateFields(ctx, part, "ticket1", []dynastorev2.FieldUpdate{
+		dynastorev2.AddToSet("tags", dynastorev2.StringSet("ci", "urgent")),
+		dynastorev2.AddToSet("watchers", dynastorev2.NumberSet(1, 2, 3)),
+		dynastorev2.AddToSet("hashes", dynastorev2.BinarySet([]byte{1}, []byte{2})),
+		dynastorev2.AppendToField("members", "b", "c"),
+	})
+	assert.NoError(err)
+
+	_, err = store.UpdateFields(ctx, part, "ticket1", []dynastorev2.FieldUpdate{
+		dynastorev2.AddToSet("tags", dynastorev2.StringSet("release")),
+		dynastorev2.DeleteFromSet("tags", dynastorev2.StringSet("urgent")),
+		dynastorev2.DeleteFromSet("watchers", dynastorev2.NumberSet(2)),
+		dynastorev2.DeleteFromSet("hashes", dynastorev2.BinarySet([]byte{1})),
+		dynastorev2.PrependToField("members", "a"),
+	})
+	assert.Error(err, "DynamoDB doesn't allow overlapping paths in a single update")
+
+	_, err = store.UpdateFields(ctx, part, "ticket1", []dynastorev2.FieldUpdate{
+		dynastorev2.DeleteFromSet("tags", dynastorev2.StringSet("urgent")),
+		dynastorev2.DeleteFromSet("watchers", dynastorev2.NumberSet(2)),
+		dynastorev2.DeleteFromSet("hashes", dynastorev2.BinarySet([]byte{1})),
+		dynastorev2.PrependToField("members", "a"),
+	})
+	assert.NoError(err)
+
+	_, err = store.UpdateFields(ctx, part, "ticket1", []dynastorev2.FieldUpdate{
+		dynastorev2.AddToSet("tags", dynastorev2.StringSet("release")),
+		dynastorev2.RemoveListElement("members", 1),
+	})
+	assert.NoError(err)
+
+	res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("test-table"),
+		Key: map[string]types.AttributeValue{
+			"id":   &types.AttributeValueMemberS{Value: part},
+			"name": &types.AttributeValueMemberS{Value: "ticket1"},
+		},
+	})
+	assert.NoError(err)
+	assert.ElementsMatch([]string{"ci", "release"}, res.Item["tags"].(*types.AttributeValueMemberSS).Value)
+	assert.ElementsMatch([]string{"1", "3"}, res.Item["watchers"].(*types.AttributeValueMemberNS).Value)
+	assert.Equal([][]byte{{2}}, res.Item["hashes"].(*types.AttributeValueMemberBS).Value)
+	assert.Equal(&types.AttributeValueMemberL{Value: []types.AttributeValue{
+		&types.AttributeValueMemberS{Value: "a"},
+		&types.AttributeValueMemberS{Value: "c"},
+	}}, res.Item["members"])
+
+	_, err = store.UpdateFields(ctx, part, "ticket1", []dynastorev2.FieldUpdate{
+		dynastorev2.AddToSet("tags", dynastorev2.StringSet()),
+	})
+	assert.Error(err)
+}
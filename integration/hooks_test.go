@@ -0,0 +1,122 @@
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+type hookCalls struct {
+	mu       sync.Mutex
+	requests []string
+	errors   []string
+	capacity []any
+}
+
+func (hc *hookCalls) storeHooks() *dynastorev2.StoreHooks[string, string, []byte] {
+	return &dynastorev2.StoreHooks[string, string, []byte]{
+		RequestBuilt: func(ctx context.Context, pk string, sk string, params any) context.Context {
+			hc.mu.Lock()
+			defer hc.mu.Unlock()
+
+			hc.requests = append(hc.requests, dynastorev2.OperationDetailsFromContext(ctx).Name)
+
+			return ctx
+		},
+		ResponseReceived: func(ctx context.Context, pk string, sk string, params any) context.Context {
+			hc.mu.Lock()
+			defer hc.mu.Unlock()
+
+			hc.capacity = append(hc.capacity, params)
+
+			return ctx
+		},
+		Error: func(ctx context.Context, details *dynastorev2.OperationDetails, err error) {
+			hc.mu.Lock()
+			defer hc.mu.Unlock()
+
+			hc.errors = append(hc.errors, details.Name)
+		},
+	}
+}
+
+func TestStoreHooks(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	calls := new(hookCalls)
+
+	store := newStore(t, dynastorev2.WithStoreHooks(calls.storeHooks()))
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"))
+	assert.NoError(err)
+
+	_, _, err = store.ListBySortKeyPrefix(ctx, part, "sort")
+	assert.NoError(err)
+
+	_, err = store.Update(ctx, part, "sort1", []byte("data"), store.WriteWithVersion(10))
+	assert.Error(err)
+
+	assert.Equal([]string{"Create", "ListBySortKeyPrefix", "Update"}, calls.requests)
+	assert.Equal([]string{"Update"}, calls.errors)
+
+	// the response hook is invoked for failed calls without any consumed capacity
+	assert.Len(calls.capacity, 3)
+	assert.NotNil(calls.capacity[1])
+	assert.Nil(calls.capacity[2])
+}
+
+func TestStoreHooksEvents(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	var (
+		requests  []*dynastorev2.RequestEvent
+		responses []*dynastorev2.ResponseEvent
+	)
+
+	store := newStore(t, dynastorev2.WithStoreHooks(&dynastorev2.StoreHooks[string, string, []byte]{
+		OnRequest: func(ctx context.Context, event *dynastorev2.RequestEvent) context.Context {
+			requests = append(requests, event)
+			return ctx
+		},
+		OnResponse: func(ctx context.Context, event *dynastorev2.ResponseEvent) {
+			responses = append(responses, event)
+		},
+	}))
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"))
+	assert.NoError(err)
+
+	_, _, err = store.ListBySortKeyPrefix(ctx, part, "sort")
+	assert.NoError(err)
+
+	_, err = store.Update(ctx, part, "sort1", []byte("data"), store.WriteWithVersion(10))
+	assert.Error(err)
+
+	assert.Len(requests, 3)
+	assert.Len(responses, 3)
+
+	assert.Equal("Create", requests[0].Name)
+	assert.Equal("UpdateItem", requests[0].Call)
+	assert.Equal(part, requests[0].PartitionKey)
+	assert.True(requests[0].Write)
+	assert.NotEmpty(requests[0].ConditionExpression)
+	assert.NotEmpty(requests[0].ExpressionAttributeNames)
+	assert.NotEmpty(requests[0].ExpressionAttributeValues)
+
+	assert.Equal("Query", requests[1].Call)
+	assert.NotEmpty(requests[1].KeyConditionExpression)
+	assert.NotEmpty(requests[1].ExpressionAttributeValues)
+	assert.Equal(1, responses[1].ItemCount)
+	assert.Greater(responses[1].CapacityUnits(), 0.0)
+
+	assert.Same(requests[2], responses[2].Request)
+	assert.Error(responses[2].Err)
+	assert.Empty(responses[2].ConsumedCapacity)
+}
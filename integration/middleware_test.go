@@ -0,0 +1,70 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/require"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+func TestMiddleware(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	var calls []string
+
+	record := func(prefix string) dynastorev2.Middleware {
+		return func(ctx context.Context, op dynastorev2.Operation, next dynastorev2.Handler) (dynastorev2.Result, error) {
+			calls = append(calls, prefix+":"+op.Name+":"+op.Call)
+			return next(ctx, op)
+		}
+	}
+
+	store := newStore(t, dynastorev2.WithMiddleware[string, string, []byte](record("outer"), record("inner")))
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"))
+	assert.NoError(err)
+
+	_, _, err = store.Get(ctx, part, "sort1")
+	assert.NoError(err)
+
+	assert.Equal([]string{
+		"outer:Create:UpdateItem", "inner:Create:UpdateItem",
+		"outer:Get:GetItem", "inner:Get:GetItem",
+	}, calls)
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	errInjected := errors.New("injected")
+
+	store := newStore(t, dynastorev2.WithMiddleware[string, string, []byte](
+		func(ctx context.Context, op dynastorev2.Operation, next dynastorev2.Handler) (dynastorev2.Result, error) {
+			switch op.Input.(type) {
+			case *dynamodb.GetItemInput:
+				// return an empty result without calling DynamoDB
+				return dynastorev2.Result{Output: &dynamodb.GetItemOutput{}}, nil
+			case *dynamodb.DeleteItemInput:
+				return dynastorev2.Result{}, errInjected
+			}
+
+			return next(ctx, op)
+		},
+	))
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"))
+	assert.NoError(err)
+
+	_, _, err = store.Get(ctx, part, "sort1")
+	assert.ErrorIs(err, dynastorev2.ErrKeyNotExists)
+
+	err = store.Delete(ctx, part, "sort1")
+	assert.ErrorIs(err, errInjected)
+}
@@ -0,0 +1,73 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteByPartition(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, []byte](t)
+	part := mustRandKey(partKeyLen)
+
+	for i := 0; i < 60; i++ {
+		_, err := store.Create(ctx, part, fmt.Sprintf("sort%02d", i), []byte("data"))
+		assert.NoError(err)
+	}
+
+	var (
+		mu       sync.Mutex
+		progress []int
+	)
+
+	_, deleted, err := store.DeleteByPartition(ctx, part, store.DeleteWithConcurrency(3), store.DeleteWithProgress(func(deleted int) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		progress = append(progress, deleted)
+	}))
+	assert.NoError(err)
+	assert.Equal(60, deleted)
+	assert.Len(progress, 3)
+	assert.Equal(60, progress[len(progress)-1])
+
+	_, vals, err := store.ListBySortKeyPrefix(ctx, part, "sort")
+	assert.NoError(err)
+	assert.Empty(vals)
+
+	_, deleted, err = store.DeleteByPartition(ctx, part)
+	assert.NoError(err)
+	assert.Equal(0, deleted)
+}
+
+func TestDeleteBySortKeyPrefix(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, []byte](t)
+	part := mustRandKey(partKeyLen)
+
+	for _, sort := range []string{"a/1", "a/2", "a/3", "b/1"} {
+		_, err := store.Create(ctx, part, sort, []byte("data"))
+		assert.NoError(err)
+	}
+
+	op, deleted, err := store.DeleteBySortKeyPrefix(ctx, part, "a/")
+	assert.NoError(err)
+	assert.Equal(3, deleted)
+	assert.NotNil(op.ConsumedCapacity)
+
+	_, vals, err := store.ListBySortKeyPrefix(ctx, part, "a/")
+	assert.NoError(err)
+	assert.Empty(vals)
+
+	_, vals, err = store.ListBySortKeyPrefix(ctx, part, "b/")
+	assert.NoError(err)
+	assert.Len(vals, 1)
+}
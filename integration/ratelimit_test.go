@@ -0,0 +1,61 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+func TestRateLimiter(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	limiter := dynastorev2.NewRateLimiter(0, 10)
+
+	store := newStore(t, dynastorev2.WithRateLimiter[string, string, []byte](limiter))
+	part := mustRandKey(partKeyLen)
+
+	start := time.Now()
+
+	// the first second of capacity is available immediately, the rest are limited to 10 WCU per second
+	for i := 0; i < 20; i++ {
+		_, err := store.Create(ctx, part, fmt.Sprintf("sort%d", i), []byte("data"))
+		assert.NoError(err)
+	}
+
+	assert.GreaterOrEqual(time.Since(start), 800*time.Millisecond)
+
+	// reads aren't limited
+	start = time.Now()
+
+	for i := 0; i < 20; i++ {
+		_, _, err := store.Get(ctx, part, fmt.Sprintf("sort%d", i))
+		assert.NoError(err)
+	}
+
+	assert.Less(time.Since(start), 800*time.Millisecond)
+}
+
+func TestRateLimiterThrottled(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	limiter := dynastorev2.NewRateLimiter(100, 100)
+
+	store := newThrottledStore(t, 1,
+		dynastorev2.WithRateLimiter[string, string, []byte](limiter),
+		dynastorev2.WithRetryPolicy[string, string, []byte](dynastorev2.RetryPolicy{BaseDelay: time.Millisecond}),
+	)
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"))
+	assert.NoError(err)
+
+	// the write rate backs off after the throttled call, reads are unaffected
+	assert.Less(limiter.WriteRate(), 100.0)
+	assert.Equal(100.0, limiter.ReadRate())
+}
@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/stretchr/testify/require"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+// newThrottledStore creates a store using a client which fails the given number of calls with a throttling error
+// before passing them through to DynamoDB
+func newThrottledStore(t *testing.T, failures int32, options ...dynastorev2.StoreOption[string, string, []byte]) *dynastorev2.Store[string, string, []byte] {
+	assert := require.New(t)
+	assert.NoError(ensureTable(context.Background(), "test-table"))
+
+	var calls atomic.Int32
+
+	throttled := dynamodb.New(client.Options(), func(o *dynamodb.Options) {
+		o.Retryer = aws.NopRetryer{}
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("throttle", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				if calls.Add(1) <= failures {
+					return middleware.InitializeOutput{}, middleware.Metadata{}, &types.ProvisionedThroughputExceededException{Message: aws.String("throttled")}
+				}
+
+				return next.HandleInitialize(ctx, in)
+			}), middleware.Before)
+		})
+	})
+
+	return dynastorev2.New(throttled, "test-table", options...)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	var retries []*dynastorev2.RetryEvent
+
+	store := newThrottledStore(t, 2,
+		dynastorev2.WithRetryPolicy[string, string, []byte](dynastorev2.RetryPolicy{BaseDelay: time.Millisecond}),
+		dynastorev2.WithStoreHooks(&dynastorev2.StoreHooks[string, string, []byte]{
+			OnRetry: func(ctx context.Context, event *dynastorev2.RetryEvent) {
+				retries = append(retries, event)
+			},
+		}),
+	)
+	part := mustRandKey(partKeyLen)
+
+	res, err := store.Create(ctx, part, "sort1", []byte("data"))
+	assert.NoError(err)
+	assert.Equal(int64(1), res.Version)
+
+	assert.Len(retries, 2)
+	assert.Equal(1, retries[0].Attempt)
+	assert.Equal(2, retries[1].Attempt)
+	assert.Equal("Create", retries[0].Request.Name)
+	assert.True(dynastorev2.IsRetryable(retries[0].Err))
+}
+
+func TestRetryPolicyMaxAttempts(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newThrottledStore(t, 5,
+		dynastorev2.WithRetryPolicy[string, string, []byte](dynastorev2.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}),
+	)
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"))
+
+	var pte *types.ProvisionedThroughputExceededException
+	assert.ErrorAs(err, &pte)
+}
+
+func TestRetryPolicyConditionCheck(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	var retries int
+
+	store := newThrottledStore(t, 0,
+		dynastorev2.WithRetryPolicy[string, string, []byte](dynastorev2.RetryPolicy{
+			BaseDelay: time.Millisecond,
+			Retryable: func(err error) bool { return true },
+		}),
+		dynastorev2.WithStoreHooks(&dynastorev2.StoreHooks[string, string, []byte]{
+			OnRetry: func(ctx context.Context, event *dynastorev2.RetryEvent) {
+				retries++
+			},
+		}),
+	)
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"))
+	assert.NoError(err)
+
+	// conditional check failures are never retried even if the policy classifies every error as retryable
+	_, err = store.Create(ctx, part, "sort1", []byte("data"))
+
+	var ccf *types.ConditionalCheckFailedException
+	assert.ErrorAs(err, &ccf)
+	assert.Zero(retries)
+}
@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	dexp "github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/stretchr/testify/require"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+func TestScan(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, []byte](t)
+	part := mustRandKey(partKeyLen)
+
+	for i := 0; i < 30; i++ {
+		_, err := store.Create(ctx, part, fmt.Sprintf("sort%02d", i), []byte("data"), store.WriteWithExtraFields(map[string]any{
+			"index": i,
+		}), store.WriteWithTTL(time.Hour))
+		assert.NoError(err)
+	}
+
+	var (
+		mu      sync.Mutex
+		records = make(map[string]dynastorev2.Record[string, string, []byte])
+	)
+
+	// other tests share the table so only records in this partition are included
+	_, err := store.Scan(ctx, func(ctx context.Context, record dynastorev2.Record[string, string, []byte]) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		records[record.SortKey] = record
+
+		return nil
+	}, store.ReadWithSegments(4), store.ReadWithConcurrency(2), store.ReadWithFilter(dexp.Name("id").Equal(dexp.Value(part))))
+	assert.NoError(err)
+	assert.Len(records, 30)
+
+	record := records["sort07"]
+	assert.Equal(part, record.PartitionKey)
+	assert.Equal([]byte("data"), record.Value)
+	assert.Equal(int64(1), record.Version)
+	assert.Equal(map[string]any{"index": float64(7)}, record.Fields)
+	assert.NotNil(record.Expires)
+}
+
+func TestScanResume(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore[string, string, []byte](t)
+	part := mustRandKey(partKeyLen)
+
+	for i := 0; i < 20; i++ {
+		_, err := store.Create(ctx, part, fmt.Sprintf("sort%02d", i), []byte("data"))
+		assert.NoError(err)
+	}
+
+	errStop := errors.New("stop")
+	seen := make(map[string]bool)
+	filter := store.ReadWithFilter(dexp.Name("id").Equal(dexp.Value(part)))
+
+	op, err := store.Scan(ctx, func(ctx context.Context, record dynastorev2.Record[string, string, []byte]) error {
+		if len(seen) == 5 {
+			return errStop
+		}
+
+		seen[record.SortKey] = true
+
+		return nil
+	}, store.ReadWithSegments(2), store.ReadWithConcurrency(1), store.ReadWithLimit(3), filter)
+	assert.ErrorIs(err, errStop)
+	assert.NotEmpty(op.LastEvaluatedKey)
+
+	// segments are restored from the cursor
+	_, err = store.Scan(ctx, func(ctx context.Context, record dynastorev2.Record[string, string, []byte]) error {
+		seen[record.SortKey] = true
+
+		return nil
+	}, store.ReadWithLastEvaluatedKey(op.LastEvaluatedKey), filter)
+	assert.NoError(err)
+	assert.Len(seen, 20)
+}
@@ -0,0 +1,156 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+type ContactV0 struct {
+	Name string
+}
+
+type ContactV1 struct {
+	FullName string
+	Country  string
+}
+
+var contactUpgrades = map[int64]dynastorev2.SchemaUpgradeFunc{
+	0: func(ctx context.Context, payload types.AttributeValue) (types.AttributeValue, error) {
+		m, ok := payload.(*types.AttributeValueMemberM)
+		if !ok {
+			return nil, fmt.Errorf("unexpected payload type %T", payload)
+		}
+
+		m.Value["FullName"] = m.Value["Name"]
+		delete(m.Value, "Name")
+
+		return m, nil
+	},
+	1: func(ctx context.Context, payload types.AttributeValue) (types.AttributeValue, error) {
+		m := payload.(*types.AttributeValueMemberM)
+		m.Value["Country"] = &types.AttributeValueMemberS{Value: "Australia"}
+
+		return m, nil
+	},
+}
+
+func TestGetWithSchemaUpgrade(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	oldStore := newStore[string, string, ContactV0](t)
+	part := mustRandKey(partKeyLen)
+
+	_, err := oldStore.Create(ctx, part, "contact1", ContactV0{Name: "Mark"})
+	assert.NoError(err)
+
+	store := newStore(t, dynastorev2.WithSchemaVersion[string, string, ContactV1](2, contactUpgrades))
+
+	op, val, err := store.Get(ctx, part, "contact1")
+	assert.NoError(err)
+	assert.Equal(ContactV1{FullName: "Mark", Country: "Australia"}, val)
+	assert.Equal(int64(1), op.Version)
+
+	_, vals, err := store.ListBySortKeyPrefix(ctx, part, "contact")
+	assert.NoError(err)
+	assert.Equal([]ContactV1{{FullName: "Mark", Country: "Australia"}}, vals)
+
+	// new writes record the current schema version so they are not upgraded again
+	_, err = store.Create(ctx, part, "contact2", ContactV1{FullName: "Jane", Country: "New Zealand"})
+	assert.NoError(err)
+
+	_, val, err = store.Get(ctx, part, "contact2")
+	assert.NoError(err)
+	assert.Equal(ContactV1{FullName: "Jane", Country: "New Zealand"}, val)
+
+	missingStore := newStore(t, dynastorev2.WithSchemaVersion[string, string, ContactV1](3, contactUpgrades))
+
+	_, _, err = missingStore.Get(ctx, part, "contact1")
+	assert.ErrorIs(err, dynastorev2.ErrSchemaUpgradeMissing)
+}
+
+func TestGetWithSchemaWriteBack(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	oldStore := newStore[string, string, ContactV0](t)
+	part := mustRandKey(partKeyLen)
+
+	_, err := oldStore.Create(ctx, part, "contact1", ContactV0{Name: "Mark"})
+	assert.NoError(err)
+
+	store := newStore(t,
+		dynastorev2.WithSchemaVersion[string, string, ContactV1](2, contactUpgrades),
+		dynastorev2.WithSchemaWriteBack[string, string, ContactV1](true),
+	)
+
+	op, val, err := store.Get(ctx, part, "contact1")
+	assert.NoError(err)
+	assert.Equal(ContactV1{FullName: "Mark", Country: "Australia"}, val)
+	assert.Equal(int64(2), op.Version)
+
+	res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("test-table"),
+		Key: map[string]types.AttributeValue{
+			"id":   &types.AttributeValueMemberS{Value: part},
+			"name": &types.AttributeValueMemberS{Value: "contact1"},
+		},
+	})
+	assert.NoError(err)
+	assert.Equal(&types.AttributeValueMemberN{Value: "2"}, res.Item["schema_version"])
+
+	// the upgraded record is read without being written again
+	op, _, err = store.Get(ctx, part, "contact1")
+	assert.NoError(err)
+	assert.Equal(int64(2), op.Version)
+}
+
+func TestGetWithSchemaWriteBackWithoutVersion(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	part := mustRandKey(partKeyLen)
+
+	// an item written outside the store has no version attribute
+	_, err := client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String("test-table"),
+		Item: map[string]types.AttributeValue{
+			"id":   &types.AttributeValueMemberS{Value: part},
+			"name": &types.AttributeValueMemberS{Value: "contact1"},
+			"payload": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+				"Name": &types.AttributeValueMemberS{Value: "Mark"},
+			}},
+		},
+	})
+	assert.NoError(err)
+
+	var conditions []string
+
+	store := newStore(t,
+		dynastorev2.WithSchemaVersion[string, string, ContactV1](2, contactUpgrades),
+		dynastorev2.WithSchemaWriteBack[string, string, ContactV1](true),
+		dynastorev2.WithMiddleware[string, string, ContactV1](func(ctx context.Context, op dynastorev2.Operation, next dynastorev2.Handler) (dynastorev2.Result, error) {
+			if input, ok := op.Input.(*dynamodb.UpdateItemInput); ok {
+				conditions = append(conditions, aws.ToString(input.ConditionExpression))
+			}
+
+			return next(ctx, op)
+		}),
+	)
+
+	op, val, err := store.Get(ctx, part, "contact1")
+	assert.NoError(err)
+	assert.Equal(ContactV1{FullName: "Mark", Country: "Australia"}, val)
+	assert.Equal(int64(1), op.Version)
+
+	// the write back is conditioned on the version still being absent so it can't overwrite a concurrent write
+	assert.Len(conditions, 1)
+	assert.Contains(conditions[0], "attribute_not_exists")
+}
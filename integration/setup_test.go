@@ -155,12 +155,14 @@ func ensureTable(ctx context.Context, tableName string) error {
 	return nil
 }
 
-func newStore[P dynastorev2.Key, S dynastorev2.Key, V any](t *testing.T) *dynastorev2.Store[P, S, V] {
+func newStore[P dynastorev2.Key, S dynastorev2.Key, V any](t *testing.T, options ...dynastorev2.StoreOption[P, S, V]) *dynastorev2.Store[P, S, V] {
 	assert := require.New(t)
 	err := ensureTable(context.Background(), "test-table")
 	assert.NoError(err)
 
-	return dynastorev2.New(client, "test-table", dynastorev2.WithStoreHooks(storeHooks[P, S, V]()))
+	options = append([]dynastorev2.StoreOption[P, S, V]{dynastorev2.WithStoreHooks(storeHooks[P, S, V]())}, options...)
+
+	return dynastorev2.New(client, "test-table", options...)
 }
 
 func storeHooks[P dynastorev2.Key, S dynastorev2.Key, V any]() *dynastorev2.StoreHooks[P, S, V] {
@@ -0,0 +1,254 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+func TestSoftDelete(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore(t, dynastorev2.WithSoftDelete[string, string, []byte](time.Hour))
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"))
+	assert.NoError(err)
+
+	_, err = store.Create(ctx, part, "sort2", []byte("data2"))
+	assert.NoError(err)
+
+	err = store.Delete(ctx, part, "sort1")
+	assert.NoError(err)
+
+	err = store.Delete(ctx, part, "sort1")
+	assert.ErrorIs(err, dynastorev2.ErrDeleteFailedKeyNotExists)
+
+	err = store.Delete(ctx, part, "sort1", store.DeleteWithCheck(false))
+	assert.NoError(err)
+
+	_, _, err = store.Get(ctx, part, "sort1")
+	assert.ErrorIs(err, dynastorev2.ErrKeyNotExists)
+
+	op, val, err := store.Get(ctx, part, "sort1", store.ReadWithDeleted(true))
+	assert.NoError(err)
+	assert.Equal([]byte("data"), val)
+	assert.Equal(int64(2), op.Version)
+
+	_, vals, err := store.ListBySortKeyPrefix(ctx, part, "sort")
+	assert.NoError(err)
+	assert.Equal([][]byte{[]byte("data2")}, vals)
+
+	_, vals, err = store.ListBySortKeyPrefix(ctx, part, "sort", store.ReadWithDeleted(true))
+	assert.NoError(err)
+	assert.Len(vals, 2)
+
+	// the tombstone is retained with a TTL of the retention period
+	res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("test-table"),
+		Key: map[string]types.AttributeValue{
+			"id":   &types.AttributeValueMemberS{Value: part},
+			"name": &types.AttributeValueMemberS{Value: "sort1"},
+		},
+	})
+	assert.NoError(err)
+	assert.Contains(res.Item, "deleted_at")
+	assert.Contains(res.Item, "expires")
+
+	_, err = store.Update(ctx, part, "sort1", []byte("data3"))
+	assert.Error(err)
+
+	op, err = store.Restore(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal(int64(3), op.Version)
+
+	_, err = store.Restore(ctx, part, "sort1")
+	assert.ErrorIs(err, dynastorev2.ErrRestoreFailedKeyNotDeleted)
+
+	_, val, err = store.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal([]byte("data"), val)
+}
+
+func TestSoftDeleteCreateAndReturn(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore(t, dynastorev2.WithSoftDelete[string, string, []byte](time.Hour))
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"), store.WriteWithExtraFields(
+		map[string]any{
+			"pk1": fmt.Sprintf("%s#%s", part, "deleted"),
+			"sk1": "20250101",
+		},
+	))
+	assert.NoError(err)
+
+	_, _, err = store.Increment(ctx, part, "sort1", "requests", 1)
+	assert.NoError(err)
+
+	op, val, err := store.DeleteAndReturn(ctx, part, "sort1", store.DeleteWithVersion(1))
+	assert.NoError(err)
+	assert.Equal([]byte("data"), val)
+	assert.Equal(int64(1), op.Version)
+
+	// a new record can be created in place of a soft deleted record
+	op, err = store.Create(ctx, part, "sort1", []byte("data2"))
+	assert.NoError(err)
+	assert.Equal(int64(1), op.Version)
+
+	op, val, err = store.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal([]byte("data2"), val)
+	assert.Equal(int64(1), op.Version)
+
+	res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("test-table"),
+		Key: map[string]types.AttributeValue{
+			"id":   &types.AttributeValueMemberS{Value: part},
+			"name": &types.AttributeValueMemberS{Value: "sort1"},
+		},
+	})
+	assert.NoError(err)
+	assert.NotContains(res.Item, "deleted_at")
+	assert.NotContains(res.Item, "deleted_expires")
+	assert.NotContains(res.Item, "expires")
+
+	// the new record doesn't inherit the extra fields or counters of the deleted record
+	assert.NotContains(res.Item, "pk1")
+	assert.NotContains(res.Item, "sk1")
+	assert.NotContains(res.Item, "requests")
+	assert.Equal(&types.AttributeValueMemberN{Value: "1"}, res.Item["version"])
+
+	_, err = store.Restore(ctx, part, "sort1")
+	assert.ErrorIs(err, dynastorev2.ErrRestoreFailedKeyNotDeleted)
+}
+
+func TestSoftDeleteRestoreTTL(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore(t, dynastorev2.WithSoftDelete[string, string, []byte](time.Hour))
+	part := mustRandKey(partKeyLen)
+
+	getItem := func() map[string]types.AttributeValue {
+		res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName: aws.String("test-table"),
+			Key: map[string]types.AttributeValue{
+				"id":   &types.AttributeValueMemberS{Value: part},
+				"name": &types.AttributeValueMemberS{Value: "sort1"},
+			},
+		})
+		assert.NoError(err)
+
+		return res.Item
+	}
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"), store.WriteWithTTL(24*time.Hour))
+	assert.NoError(err)
+
+	expires := getItem()["expires"]
+	assert.NotNil(expires)
+
+	err = store.Delete(ctx, part, "sort1")
+	assert.NoError(err)
+
+	// the tombstone expires after the retention period, saving the original TTL so it can be restored
+	item := getItem()
+	assert.NotEqual(expires, item["expires"])
+	assert.Equal(expires, item["deleted_expires"])
+
+	op, err := store.Restore(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal(int64(3), op.Version)
+
+	item = getItem()
+	assert.Equal(expires, item["expires"])
+	assert.NotContains(item, "deleted_at")
+	assert.NotContains(item, "deleted_expires")
+
+	// a record which didn't expire before it was deleted doesn't expire once restored
+	_, err = store.Update(ctx, part, "sort1", []byte("data2"), store.WriteWithTTLCleared())
+	assert.NoError(err)
+
+	err = store.Delete(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal(&types.AttributeValueMemberN{Value: "0"}, getItem()["deleted_expires"])
+
+	_, err = store.Restore(ctx, part, "sort1")
+	assert.NoError(err)
+
+	item = getItem()
+	assert.NotContains(item, "expires")
+	assert.NotContains(item, "deleted_expires")
+}
+
+func TestSoftDeleteUpsert(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore(t, dynastorev2.WithSoftDelete[string, string, []byte](time.Hour))
+	part := mustRandKey(partKeyLen)
+
+	getItem := func() map[string]types.AttributeValue {
+		res, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName: aws.String("test-table"),
+			Key: map[string]types.AttributeValue{
+				"id":   &types.AttributeValueMemberS{Value: part},
+				"name": &types.AttributeValueMemberS{Value: "sort1"},
+			},
+		})
+		assert.NoError(err)
+
+		return res.Item
+	}
+
+	_, err := store.Create(ctx, part, "sort1", []byte("data"), store.WriteWithTTL(24*time.Hour), store.WriteWithExtraFields(
+		map[string]any{"pk1": fmt.Sprintf("%s#%s", part, "live")},
+	))
+	assert.NoError(err)
+
+	expires := getItem()["expires"]
+	assert.NotNil(expires)
+
+	// an upsert of a live record updates it in place, retaining the TTL and extra fields
+	op, err := store.Create(ctx, part, "sort1", []byte("data2"), store.WriteWithCreateConstraintDisabled(true))
+	assert.NoError(err)
+	assert.Equal(int64(2), op.Version)
+
+	item := getItem()
+	assert.Equal(expires, item["expires"])
+	assert.Contains(item, "pk1")
+
+	_, _, err = store.Increment(ctx, part, "sort1", "requests", 1)
+	assert.NoError(err)
+
+	err = store.Delete(ctx, part, "sort1")
+	assert.NoError(err)
+
+	// an upsert of a tombstone replaces the whole item
+	op, err = store.Create(ctx, part, "sort1", []byte("data3"), store.WriteWithCreateConstraintDisabled(true))
+	assert.NoError(err)
+	assert.Equal(int64(1), op.Version)
+
+	item = getItem()
+	assert.NotContains(item, "expires")
+	assert.NotContains(item, "deleted_at")
+	assert.NotContains(item, "deleted_expires")
+	assert.NotContains(item, "pk1")
+	assert.NotContains(item, "requests")
+	assert.Equal(&types.AttributeValueMemberN{Value: "1"}, item["version"])
+
+	_, val, err := store.Get(ctx, part, "sort1")
+	assert.NoError(err)
+	assert.Equal([]byte("data3"), val)
+}
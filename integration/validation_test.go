@@ -0,0 +1,60 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wolfeidau/dynastorev2"
+)
+
+type Ticket struct {
+	Title  string `json:"title,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+func (t *Ticket) Validate() error {
+	if t.Title == "" {
+		return errors.New("title is required")
+	}
+
+	return nil
+}
+
+func TestCreateWithValidation(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	store := newStore(t, dynastorev2.WithValidator[string, string, Ticket](func(ticket Ticket) error {
+		if ticket.Status == "" {
+			return errors.New("status is required")
+		}
+
+		return nil
+	}))
+	part := mustRandKey(partKeyLen)
+
+	_, err := store.Create(ctx, part, "ticket1", Ticket{Title: "broken build"})
+	var verr *dynastorev2.ValidationError
+	assert.ErrorAs(err, &verr)
+	assert.EqualError(verr.Err, "status is required")
+
+	// the Validate method implemented by the value is also called
+	_, err = store.Create(ctx, part, "ticket1", Ticket{Status: "open"})
+	assert.ErrorAs(err, &verr)
+	assert.EqualError(verr.Err, "title is required")
+
+	_, _, err = store.Get(ctx, part, "ticket1")
+	assert.ErrorIs(err, dynastorev2.ErrKeyNotExists)
+
+	_, err = store.Create(ctx, part, "ticket1", Ticket{Title: "broken build", Status: "open"})
+	assert.NoError(err)
+
+	_, err = store.Update(ctx, part, "ticket1", Ticket{Title: "broken build"})
+	assert.ErrorAs(err, &verr)
+
+	_, val, err := store.Get(ctx, part, "ticket1")
+	assert.NoError(err)
+	assert.Equal(Ticket{Title: "broken build", Status: "open"}, val)
+}
@@ -0,0 +1,92 @@
+package dynastorev2
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// Operation describes a call made to DynamoDB by a store operation
+type Operation struct {
+	// Name of the store operation, for example Create or ListBySortKeyPrefix
+	Name string
+	// Call is the name of the DynamoDB API being called, for example UpdateItem or Query
+	Call string
+	// Table the call is made against
+	Table string
+	// PartitionKey of the store operation, empty for operations which span partitions
+	PartitionKey string
+	// SortKey of the store operation, empty for operations which span sort keys
+	SortKey string
+	// Input is the AWS SDK input for the call, for example *dynamodb.UpdateItemInput
+	Input any
+}
+
+// Result holds the outcome of a call made to DynamoDB
+type Result struct {
+	// Output is the AWS SDK output for the call, for example *dynamodb.UpdateItemOutput
+	Output any
+}
+
+// Handler makes the call described by the operation
+type Handler func(ctx context.Context, op Operation) (Result, error)
+
+// Middleware wraps each call made to DynamoDB, it can inspect or modify the operation before calling next, retry the
+// call or return a result without calling next at all.
+//
+// A middleware which returns a result without calling next must provide an Output of the same type the AWS SDK would,
+// for example *dynamodb.GetItemOutput for a GetItem call.
+type Middleware func(ctx context.Context, op Operation, next Handler) (Result, error)
+
+// chainMiddleware builds a handler which passes each operation through the middleware in the order provided before
+// invoking the final handler
+func chainMiddleware(middleware []Middleware, final Handler) Handler {
+	h := final
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		mw, next := middleware[i], h
+
+		h = func(ctx context.Context, op Operation) (Result, error) {
+			return mw(ctx, op, next)
+		}
+	}
+
+	return h
+}
+
+// send dispatches the request to DynamoDB through the middleware registered with the store, retries are made inside
+// the middleware and the store hooks are invoked around each call made to the AWS SDK
+func send[P Key, S Key, V any, In any, Out any](ctx context.Context, t *Store[P, S, V], partitionKey P, sortKey S, params *In, call func(context.Context, *In, ...func(*dynamodb.Options)) (*Out, error)) (*Out, error) {
+	if len(t.storeOptions.middleware) == 0 {
+		return sendWithRetry(ctx, t, partitionKey, sortKey, params, call)
+	}
+
+	op := newRequestEvent(ctx, t.tableName, params).Operation
+
+	h := chainMiddleware(t.storeOptions.middleware, func(ctx context.Context, op Operation) (Result, error) {
+		in, ok := op.Input.(*In)
+		if !ok {
+			return Result{}, fmt.Errorf("dynastorev2: middleware changed the input type for %s to %T", op.Call, op.Input)
+		}
+
+		out, err := sendWithRetry(ctx, t, partitionKey, sortKey, in, call)
+		if err != nil {
+			return Result{}, err
+		}
+
+		return Result{Output: out}, nil
+	})
+
+	res, err := h(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+
+	out, ok := res.Output.(*Out)
+	if !ok {
+		return nil, fmt.Errorf("dynastorev2: middleware returned %T for %s", res.Output, op.Call)
+	}
+
+	return out, nil
+}
@@ -2,6 +2,9 @@ package dynastorev2
 
 import (
 	"time"
+
+	dexp "github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 // StoreOption sets a specific store option
@@ -11,7 +14,20 @@ type StoreOption[P Key, S Key, V any] interface {
 
 // StoreOptions holds all available store configuration options
 type StoreOptions[P Key, S Key, V any] struct {
-	storeHooks *StoreHooks[P, S, V]
+	storeHooks          *StoreHooks[P, S, V]
+	keyProvider         KeyProvider
+	blobStore           BlobStore
+	blobThreshold       int
+	schemaVersion       int64
+	schemaUpgrades      map[int64]SchemaUpgradeFunc
+	schemaWriteBack     bool
+	validator           func(V) error
+	softDeleteRetention time.Duration
+	middleware          []Middleware
+	consumedCapacity    types.ReturnConsumedCapacity
+	retryPolicy         *RetryPolicy
+	rateLimiter         *RateLimiter
+	cache               Cache
 }
 
 // StoreOptionFunc wraps a function and implements the StoreOption interface
@@ -35,6 +51,129 @@ func WithStoreHooks[P Key, S Key, V any](storeHooks *StoreHooks[P, S, V]) StoreO
 	})
 }
 
+// WithReturnConsumedCapacity sets the level of detail of the consumed capacity returned by DynamoDB, defaults to TOTAL.
+//
+// INDEXES adds a breakdown of the capacity consumed by the table and each index, which is available using the
+// IndexCapacityUnits method of OperationResult, while NONE omits it which leaves ConsumedCapacity in OperationResult nil.
+func WithReturnConsumedCapacity[P Key, S Key, V any](consumedCapacity types.ReturnConsumedCapacity) StoreOption[P, S, V] {
+	return StoreOptionFunc[P, S, V](func(opts *StoreOptions[P, S, V]) {
+		opts.consumedCapacity = consumedCapacity
+	})
+}
+
+// WithRetryPolicy retries calls to DynamoDB which fail with throttling or transient errors using the policy, each retry
+// is reported to the OnRetry store hook.
+//
+// Note the AWS SDK client also retries throttled calls using its own retryer, to rely on this policy alone configure
+// the client with aws.NopRetryer.
+func WithRetryPolicy[P Key, S Key, V any](policy RetryPolicy) StoreOption[P, S, V] {
+	return StoreOptionFunc[P, S, V](func(opts *StoreOptions[P, S, V]) {
+		opts.retryPolicy = &policy
+	})
+}
+
+// WithRateLimiter limits the capacity consumed by calls made to DynamoDB using the rate limiter, calls wait before being
+// sent while the limiter is out of capacity. Each attempt made by the retry policy is limited.
+//
+// The limiter is charged with the consumed capacity returned by DynamoDB, if this is disabled using
+// WithReturnConsumedCapacity each call is charged one unit per item.
+func WithRateLimiter[P Key, S Key, V any](limiter *RateLimiter) StoreOption[P, S, V] {
+	return StoreOptionFunc[P, S, V](func(opts *StoreOptions[P, S, V]) {
+		opts.rateLimiter = limiter
+	})
+}
+
+// WithCache enables a read through cache for Get and GetRecord, items are added to the cache when they are read,
+// created or updated. Deleting a record, or modifying it without the new item being returned, leaves an entry without
+// data which prevents older versions being cached. Entries expire with the record.
+//
+// Notes:
+// 1. Reads using ReadWithConsistentRead(true) or ReadWithDeleted(true) bypass the cache.
+// 2. A read served by the cache doesn't return any consumed capacity.
+// 3. The in process LRUCache holds decoded records, so hits don't decrypt payloads or read them from the blob store.
+// The value is shared by every read served from the entry so it must not be modified.
+// 4. External caches store the item as DynamoDB JSON, so encrypted payloads remain encrypted, but each hit decrypts the
+// payload and reads offloaded payloads from the blob store.
+// 5. Changes made by other processes aren't seen until the entry expires, use the ttl of NewLRUCache to bound this.
+// 6. Errors returned by the cache are ignored, the record is read from the table instead.
+func WithCache[P Key, S Key, V any](cache Cache) StoreOption[P, S, V] {
+	return StoreOptionFunc[P, S, V](func(opts *StoreOptions[P, S, V]) {
+		opts.cache = cache
+	})
+}
+
+// WithMiddleware adds middleware which wraps every call made to DynamoDB by the store, middleware is invoked in the
+// order it is added so the first is the outermost.
+//
+// Notes:
+// 1. The store hooks are invoked inside the middleware around each call made to the AWS SDK, so a middleware which retries
+// a call will trigger the hooks for each attempt, and one which returns a result without calling next won't trigger them.
+// 2. Calls to the blob store are not passed through the middleware.
+func WithMiddleware[P Key, S Key, V any](middleware ...Middleware) StoreOption[P, S, V] {
+	return StoreOptionFunc[P, S, V](func(opts *StoreOptions[P, S, V]) {
+		opts.middleware = append(opts.middleware, middleware...)
+	})
+}
+
+// WithEncryption enables client side envelope encryption of the payload using AES-GCM, each item is encrypted with
+// a new data key which is wrapped by the key provider and stored alongside the item.
+func WithEncryption[P Key, S Key, V any](keyProvider KeyProvider) StoreOption[P, S, V] {
+	return StoreOptionFunc[P, S, V](func(opts *StoreOptions[P, S, V]) {
+		opts.keyProvider = keyProvider
+	})
+}
+
+// WithBlobStore enables offloading of payloads which are larger than the threshold in bytes to the blob store, the item
+// retains a pointer and checksum which are used to fetch the payload when it is read. If the threshold is zero
+// DefaultBlobThreshold is used.
+func WithBlobStore[P Key, S Key, V any](blobStore BlobStore, threshold int) StoreOption[P, S, V] {
+	return StoreOptionFunc[P, S, V](func(opts *StoreOptions[P, S, V]) {
+		if threshold <= 0 {
+			threshold = DefaultBlobThreshold
+		}
+
+		opts.blobStore = blobStore
+		opts.blobThreshold = threshold
+	})
+}
+
+// WithSchemaVersion records the current schema version of the payload with each write, when older records are read the
+// upgrade functions are run in sequence to bring the payload up to the current version. Each upgrade function is
+// keyed by the version it upgrades from, records without a schema version are treated as version zero.
+func WithSchemaVersion[P Key, S Key, V any](version int64, upgrades map[int64]SchemaUpgradeFunc) StoreOption[P, S, V] {
+	return StoreOptionFunc[P, S, V](func(opts *StoreOptions[P, S, V]) {
+		opts.schemaVersion = version
+		opts.schemaUpgrades = upgrades
+	})
+}
+
+// WithSchemaWriteBack enables persisting upgraded payloads when they are read, this uses an update conditioned on the
+// version read so it won't overwrite concurrent changes.
+func WithSchemaWriteBack[P Key, S Key, V any](enabled bool) StoreOption[P, S, V] {
+	return StoreOptionFunc[P, S, V](func(opts *StoreOptions[P, S, V]) {
+		opts.schemaWriteBack = enabled
+	})
+}
+
+// WithValidator registers a function which validates values before they are written, if it returns an error the write
+// fails with a ValidationError. Values which implement Validator are also validated using their Validate method.
+func WithValidator[P Key, S Key, V any](validator func(V) error) StoreOption[P, S, V] {
+	return StoreOptionFunc[P, S, V](func(opts *StoreOptions[P, S, V]) {
+		opts.validator = validator
+	})
+}
+
+// WithSoftDelete enables soft deletes, rather than removing records Delete marks them with a tombstone and assigns a
+// TTL of the retention period, during which they can be brought back using Restore.
+//
+// Soft deleted records are hidden from Get and ListBySortKeyPrefix unless ReadWithDeleted is used. Note any payload
+// offloaded to a blob store is retained, as it is required to restore the record, and isn't removed when it expires.
+func WithSoftDelete[P Key, S Key, V any](retention time.Duration) StoreOption[P, S, V] {
+	return StoreOptionFunc[P, S, V](func(opts *StoreOptions[P, S, V]) {
+		opts.softDeleteRetention = retention
+	})
+}
+
 // Option sets a specific write option
 type WriteOption[P Key, S Key, V any] interface {
 	Apply(opts *WriteOptions[P, S, V])
@@ -43,9 +182,13 @@ type WriteOption[P Key, S Key, V any] interface {
 // options holds all available write configuration options
 type WriteOptions[P Key, S Key, V any] struct {
 	extraFields              map[string]any
+	removeFields             []string
 	ttl                      time.Duration
+	ttlCleared               bool
 	version                  int64
 	createConstraintDisabled bool
+	createIfMissing          bool
+	condition                dexp.ConditionBuilder
 }
 
 // WriteOptionFunc wraps a function and implements the WriteOption interface
@@ -67,6 +210,15 @@ func ApplyWriteOptions[P Key, S Key, V any](v *WriteOptions[P, S, V], opts ...Wr
 func writeWithTTL[P Key, S Key, V any](ttl time.Duration) WriteOption[P, S, V] {
 	return WriteOptionFunc[P, S, V](func(opts *WriteOptions[P, S, V]) {
 		opts.ttl = ttl
+		opts.ttlCleared = false
+	})
+}
+
+// writeWithTTLCleared removes the time to live (TTL) from the record when it is updated
+func writeWithTTLCleared[P Key, S Key, V any]() WriteOption[P, S, V] {
+	return WriteOptionFunc[P, S, V](func(opts *WriteOptions[P, S, V]) {
+		opts.ttl = 0
+		opts.ttlCleared = true
 	})
 }
 
@@ -84,6 +236,20 @@ func writeWithExtraFields[P Key, S Key, V any](extraFields map[string]any) Write
 	})
 }
 
+// writeWithCondition adds a condition which must be met for the write to succeed, multiple conditions are combined using AND
+func writeWithCondition[P Key, S Key, V any](condition dexp.ConditionBuilder) WriteOption[P, S, V] {
+	return WriteOptionFunc[P, S, V](func(opts *WriteOptions[P, S, V]) {
+		opts.condition = andConditions(opts.condition, condition)
+	})
+}
+
+// writeWithRemoveFields removes the named extra fields from the record when it is updated
+func writeWithRemoveFields[P Key, S Key, V any](names []string) WriteOption[P, S, V] {
+	return WriteOptionFunc[P, S, V](func(opts *WriteOptions[P, S, V]) {
+		opts.removeFields = append(opts.removeFields, names...)
+	})
+}
+
 // WriteWithCreateConstraintDisabled disable the check on create for existence of the rows
 func writeWithCreateConstraintDisabled[P Key, S Key, V any](createConstraintDisabled bool) WriteOption[P, S, V] {
 	return WriteOptionFunc[P, S, V](func(opts *WriteOptions[P, S, V]) {
@@ -91,6 +257,13 @@ func writeWithCreateConstraintDisabled[P Key, S Key, V any](createConstraintDisa
 	})
 }
 
+// writeWithCreateIfMissing create the record if it doesn't exist when incrementing counters
+func writeWithCreateIfMissing[P Key, S Key, V any](createIfMissing bool) WriteOption[P, S, V] {
+	return WriteOptionFunc[P, S, V](func(opts *WriteOptions[P, S, V]) {
+		opts.createIfMissing = createIfMissing
+	})
+}
+
 // ReadOptions sets a specific read option
 type ReadOption[P Key, S Key] interface {
 	Apply(opts *ReadOptions[P, S])
@@ -105,6 +278,10 @@ type ReadOptions[P Key, S Key] struct {
 	indexName          string
 	indexPartKey       string // the name of the partition key in the index
 	indexSortKey       string // the name of the sort key in the index
+	includeDeleted     bool
+	segments           int
+	concurrency        int
+	filter             dexp.ConditionBuilder
 }
 
 // ReadOptionFunc wraps a function and implements the ReadOption interface
@@ -159,6 +336,35 @@ func readWithIndex[P Key, S Key](name, partKey, sortKey string) ReadOption[P, S]
 	})
 }
 
+// readWithDeleted include soft deleted records when performing get and list operations
+func readWithDeleted[P Key, S Key](includeDeleted bool) ReadOption[P, S] {
+	return ReadOptionFunc[P, S](func(opts *ReadOptions[P, S]) {
+		opts.includeDeleted = includeDeleted
+	})
+}
+
+// readWithSegments sets the number of segments the table is divided into when performing scan operations
+func readWithSegments[P Key, S Key](segments int) ReadOption[P, S] {
+	return ReadOptionFunc[P, S](func(opts *ReadOptions[P, S]) {
+		opts.segments = segments
+	})
+}
+
+// readWithConcurrency sets the maximum number of segments read concurrently when performing scan operations
+func readWithConcurrency[P Key, S Key](concurrency int) ReadOption[P, S] {
+	return ReadOptionFunc[P, S](func(opts *ReadOptions[P, S]) {
+		opts.concurrency = concurrency
+	})
+}
+
+// readWithFilter adds a filter which records must match when performing list and scan operations, multiple filters
+// are combined using AND
+func readWithFilter[P Key, S Key](filter dexp.ConditionBuilder) ReadOption[P, S] {
+	return ReadOptionFunc[P, S](func(opts *ReadOptions[P, S]) {
+		opts.filter = andConditions(opts.filter, filter)
+	})
+}
+
 // DeleteOption sets a specific delete option
 type DeleteOption[P Key, S Key] interface {
 	Apply(opts *DeleteOptions[P, S])
@@ -167,6 +373,10 @@ type DeleteOption[P Key, S Key] interface {
 // DeleteOptions holds all available delete configuration options
 type DeleteOptions[P Key, S Key] struct {
 	existsCheck bool
+	version     int64
+	condition   dexp.ConditionBuilder
+	concurrency int
+	progress    func(deleted int)
 }
 
 // deleteOptionFunc wraps a function and implements the DeleteOption interface
@@ -189,3 +399,31 @@ func deleteWithCheck[P Key, S Key](enabled bool) DeleteOption[P, S] {
 		opts.existsCheck = enabled
 	})
 }
+
+// deleteWithVersion adds a condition check the provided version to enable optimistic locking
+func deleteWithVersion[P Key, S Key](version int64) DeleteOption[P, S] {
+	return deleteOptionFunc[P, S](func(opts *DeleteOptions[P, S]) {
+		opts.version = version
+	})
+}
+
+// deleteWithConcurrency sets the maximum number of batched deletes which are run concurrently
+func deleteWithConcurrency[P Key, S Key](concurrency int) DeleteOption[P, S] {
+	return deleteOptionFunc[P, S](func(opts *DeleteOptions[P, S]) {
+		opts.concurrency = concurrency
+	})
+}
+
+// deleteWithProgress sets a callback which is invoked with the total number of records deleted after each batch
+func deleteWithProgress[P Key, S Key](progress func(deleted int)) DeleteOption[P, S] {
+	return deleteOptionFunc[P, S](func(opts *DeleteOptions[P, S]) {
+		opts.progress = progress
+	})
+}
+
+// deleteWithCondition adds a condition which must be met for the delete to succeed, multiple conditions are combined using AND
+func deleteWithCondition[P Key, S Key](condition dexp.ConditionBuilder) DeleteOption[P, S] {
+	return deleteOptionFunc[P, S](func(opts *DeleteOptions[P, S]) {
+		opts.condition = andConditions(opts.condition, condition)
+	})
+}
@@ -0,0 +1,215 @@
+package dynastorev2
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// encodedPayload the attributes which are written to or removed from an item to store a value
+type encodedPayload struct {
+	attributes map[string]types.AttributeValue
+	remove     []string
+	blobKey    string // the key of the blob written for this payload if it was offloaded
+}
+
+// encodePayload marshals the value and returns the attributes which should be written to the item to store it,
+// if encryption is enabled this will include the encrypted payload along with the key id and wrapped data key.
+//
+// If a blob store is configured and the payload exceeds the threshold it is offloaded, leaving a pointer and checksum in the item.
+func (t *Store[P, S, V]) encodePayload(ctx context.Context, key map[string]types.AttributeValue, value V) (*encodedPayload, error) {
+	val, err := attributevalue.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to marshal value: %w", err)
+	}
+
+	payload := &encodedPayload{
+		attributes: map[string]types.AttributeValue{
+			t.fields.payloadName: val,
+		},
+		// remove the key attributes left over from a previously encrypted payload, otherwise the plaintext payload
+		// would be decrypted when read
+		remove: []string{t.fields.keyIDName, t.fields.dataKeyName},
+	}
+
+	if t.storeOptions.keyProvider != nil {
+		plaintext, err := encodeAttributeValue(val)
+		if err != nil {
+			return nil, err
+		}
+
+		aad, err := keyAAD(key[t.fields.partitionKeyName], key[t.fields.sortKeyName])
+		if err != nil {
+			return nil, err
+		}
+
+		ciphertext, keyID, wrappedKey, err := encryptPayload(ctx, t.storeOptions.keyProvider, plaintext, aad)
+		if err != nil {
+			return nil, err
+		}
+
+		payload.attributes = map[string]types.AttributeValue{
+			t.fields.payloadName: &types.AttributeValueMemberB{Value: ciphertext},
+			t.fields.keyIDName:   &types.AttributeValueMemberS{Value: keyID},
+			t.fields.dataKeyName: &types.AttributeValueMemberB{Value: wrappedKey},
+		}
+		payload.remove = nil
+	}
+
+	// payload fits in the item, or can't be offloaded, so remove any pointer left over from a previously offloaded
+	// payload as it takes precedence over the payload when read
+	if t.storeOptions.blobStore == nil || attributeSize(payload.attributes[t.fields.payloadName]) <= t.storeOptions.blobThreshold {
+		payload.remove = append(payload.remove, t.fields.blobKeyName, t.fields.blobChecksumName)
+		return payload, nil
+	}
+
+	data, err := encodeAttributeValue(payload.attributes[t.fields.payloadName])
+	if err != nil {
+		return nil, err
+	}
+
+	payload.blobKey, err = newBlobKey(key, t.fields.partitionKeyName, t.fields.sortKeyName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.storeOptions.blobStore.PutBlob(ctx, payload.blobKey, data)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to put blob: %w", err)
+	}
+
+	delete(payload.attributes, t.fields.payloadName)
+	payload.attributes[t.fields.blobKeyName] = &types.AttributeValueMemberS{Value: payload.blobKey}
+	payload.attributes[t.fields.blobChecksumName] = &types.AttributeValueMemberS{Value: blobChecksum(data)}
+	payload.remove = append(payload.remove, t.fields.payloadName)
+
+	return payload, nil
+}
+
+// decodePayload extracts the payload from the item and unmarshals it into the value, fetching it from the blob store if
+// it was offloaded and decrypting it if it was written with encryption enabled.
+//
+// If schema versioning is enabled the payload is upgraded to the current version before it is unmarshalled, in which
+// case true is returned to indicate the value differs from the stored payload.
+func (t *Store[P, S, V]) decodePayload(ctx context.Context, item map[string]types.AttributeValue) (V, bool, error) {
+	var val V
+
+	payload, ok := item[t.fields.payloadName]
+
+	if blobKeyAttr, isBlob := item[t.fields.blobKeyName]; isBlob {
+		var err error
+
+		payload, err = t.fetchBlob(ctx, item, blobKeyAttr)
+		if err != nil {
+			return val, false, err
+		}
+
+		ok = true
+	}
+
+	if !ok {
+		return val, false, nil
+	}
+
+	if keyAttr, ok := item[t.fields.keyIDName]; ok {
+		var err error
+
+		payload, err = t.decryptPayload(ctx, item, payload, keyAttr)
+		if err != nil {
+			return val, false, err
+		}
+	}
+
+	payload, upgraded, err := t.upgradePayload(ctx, item, payload)
+	if err != nil {
+		return val, false, err
+	}
+
+	err = attributevalue.Unmarshal(payload, &val)
+	if err != nil {
+		return val, false, fmt.Errorf("dynastorev2: failed to unmarshal payload attribute: %w", err)
+	}
+
+	return val, upgraded, nil
+}
+
+func (t *Store[P, S, V]) decryptPayload(ctx context.Context, item map[string]types.AttributeValue, payload, keyAttr types.AttributeValue) (types.AttributeValue, error) {
+	if t.storeOptions.keyProvider == nil {
+		return nil, ErrKeyProviderMissing
+	}
+
+	var (
+		keyID      string
+		wrappedKey []byte
+		ciphertext []byte
+	)
+
+	err := attributevalue.Unmarshal(keyAttr, &keyID)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to extract key id attribute: %w", err)
+	}
+
+	err = attributevalue.Unmarshal(item[t.fields.dataKeyName], &wrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to extract data key attribute: %w", err)
+	}
+
+	err = attributevalue.Unmarshal(payload, &ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to extract encrypted payload attribute: %w", err)
+	}
+
+	aad, err := keyAAD(item[t.fields.partitionKeyName], item[t.fields.sortKeyName])
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := decryptPayload(ctx, t.storeOptions.keyProvider, ciphertext, keyID, wrappedKey, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeAttributeValue(plaintext)
+}
+
+func (t *Store[P, S, V]) fetchBlob(ctx context.Context, item map[string]types.AttributeValue, blobKeyAttr types.AttributeValue) (types.AttributeValue, error) {
+	if t.storeOptions.blobStore == nil {
+		return nil, ErrBlobStoreMissing
+	}
+
+	var blobKey, checksum string
+
+	err := attributevalue.Unmarshal(blobKeyAttr, &blobKey)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to extract blob key attribute: %w", err)
+	}
+
+	err = attributevalue.Unmarshal(item[t.fields.blobChecksumName], &checksum)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to extract blob checksum attribute: %w", err)
+	}
+
+	data, err := t.storeOptions.blobStore.GetBlob(ctx, blobKey)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to get blob: %w", err)
+	}
+
+	if blobChecksum(data) != checksum {
+		return nil, ErrBlobChecksumMismatch
+	}
+
+	return decodeAttributeValue(data)
+}
+
+// blobKeyFromItem returns the key of the blob referenced by the item, or an empty string if the payload wasn't offloaded
+func (t *Store[P, S, V]) blobKeyFromItem(item map[string]types.AttributeValue) string {
+	var blobKey string
+
+	if attr, ok := item[t.fields.blobKeyName]; ok {
+		_ = attributevalue.Unmarshal(attr, &blobKey)
+	}
+
+	return blobKey
+}
@@ -0,0 +1,277 @@
+package dynastorev2
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	dexp "github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// batchWriteMaxItems is the maximum number of items DynamoDB accepts in a single BatchWriteItem call
+	batchWriteMaxItems = 25
+
+	batchWriteMaxAttempts = 8
+	batchWriteBaseDelay   = 50 * time.Millisecond
+	batchWriteMaxDelay    = 5 * time.Second
+)
+
+// DeleteByPartition deletes all the records in DynamoDB with the provided partition key, returning the number of
+// records deleted.
+//
+// Notes:
+// 1. The keys are read using a query then removed using batched deletes, so records written while this is running may not be deleted.
+// 2. Batched deletes don't support conditions so DeleteWithCheck, DeleteWithVersion and DeleteWithCondition are ignored.
+// 3. Records are always removed, even when soft delete is enabled.
+// 4. Use DeleteWithConcurrency and DeleteWithProgress to control the rate of deletes and track progress.
+func (t *Store[P, S, V]) DeleteByPartition(ctx context.Context, partitionKey P, options ...DeleteOption[P, S]) (*OperationResult, int, error) {
+	ctx = setOperationDetails(ctx, "DeleteByPartition", partitionKey, "")
+
+	keyCond := dexp.KeyEqual(dexp.Key(t.fields.partitionKeyName), dexp.Value(partitionKey))
+
+	return t.deleteByKeyCondition(ctx, partitionKey, keyCond, options...)
+}
+
+// DeleteBySortKeyPrefix deletes all the records in DynamoDB with the provided partition key and a sort key starting
+// with the prefix, returning the number of records deleted.
+//
+// See DeleteByPartition for more details.
+func (t *Store[P, S, V]) DeleteBySortKeyPrefix(ctx context.Context, partitionKey P, prefix string, options ...DeleteOption[P, S]) (*OperationResult, int, error) {
+	ctx = setOperationDetails(ctx, "DeleteBySortKeyPrefix", partitionKey, prefix)
+
+	keyCond := dexp.KeyEqual(dexp.Key(t.fields.partitionKeyName), dexp.Value(partitionKey)).
+		And(dexp.KeyBeginsWith(dexp.Key(t.fields.sortKeyName), prefix))
+
+	return t.deleteByKeyCondition(ctx, partitionKey, keyCond, options...)
+}
+
+// DeleteWithConcurrency sets the maximum number of batched deletes which are run concurrently, defaults to one
+func (t *Store[P, S, V]) DeleteWithConcurrency(concurrency int) DeleteOption[P, S] {
+	return deleteWithConcurrency[P, S](concurrency)
+}
+
+// DeleteWithProgress sets a callback which is invoked with the total number of records deleted after each batch
+func (t *Store[P, S, V]) DeleteWithProgress(progress func(deleted int)) DeleteOption[P, S] {
+	return deleteWithProgress[P, S](progress)
+}
+
+func (t *Store[P, S, V]) deleteByKeyCondition(ctx context.Context, partitionKey P, keyCond dexp.KeyConditionBuilder, options ...DeleteOption[P, S]) (*OperationResult, int, error) {
+	defaultOpts := t.defaultDeleteOptions()
+	ApplyDeleteOptions(defaultOpts, options...)
+
+	// only the keys are needed to delete the records, along with the blob key to clean up any offloaded payloads
+	projection := dexp.NamesList(dexp.Name(t.fields.partitionKeyName), dexp.Name(t.fields.sortKeyName))
+	if t.storeOptions.blobStore != nil {
+		projection = projection.AddNames(dexp.Name(t.fields.blobKeyName))
+	}
+
+	expr, err := dexp.NewBuilder().WithKeyCondition(keyCond).WithProjection(projection).Build()
+	if err != nil {
+		return nil, 0, fmt.Errorf("dynastorev2: failed to build query expression: %w", err)
+	}
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:                 aws.String(t.tableName),
+		ReturnConsumedCapacity:    t.storeOptions.consumedCapacity,
+		KeyConditionExpression:    expr.KeyCondition(),
+		ProjectionExpression:      expr.Projection(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	concurrency := defaultOpts.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		deleted  int
+		firstErr error
+		capacity = &types.ConsumedCapacity{TableName: aws.String(t.tableName), CapacityUnits: aws.Float64(0)}
+	)
+
+	sem := make(chan struct{}, concurrency)
+
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	var sortKey S
+
+	for {
+		res, err := send(ctx, t, partitionKey, sortKey, queryInput, t.client.Query)
+		if err != nil {
+			fail(fmt.Errorf("dynastorev2: failed to execute query: %w", err))
+			break
+		}
+
+		if res.ConsumedCapacity != nil {
+			mu.Lock()
+			addCapacity(capacity, *res.ConsumedCapacity)
+			mu.Unlock()
+		}
+
+		for start := 0; start < len(res.Items); start += batchWriteMaxItems {
+			items := res.Items[start:min(start+batchWriteMaxItems, len(res.Items))]
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+			}
+
+			if ctx.Err() != nil {
+				break
+			}
+
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				used, err := t.deleteBatch(ctx, partitionKey, items)
+
+				mu.Lock()
+				addCapacity(capacity, used...)
+				mu.Unlock()
+
+				if err != nil {
+					fail(err)
+					return
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				deleted += len(items)
+
+				if defaultOpts.progress != nil {
+					defaultOpts.progress(deleted)
+				}
+			}()
+		}
+
+		if len(res.LastEvaluatedKey) == 0 || ctx.Err() != nil {
+			break
+		}
+
+		queryInput.ExclusiveStartKey = res.LastEvaluatedKey
+	}
+
+	wg.Wait()
+
+	if firstErr == nil && ctx.Err() != nil {
+		firstErr = ctx.Err()
+	}
+
+	if firstErr != nil {
+		return nil, deleted, firstErr
+	}
+
+	return &OperationResult{
+		ConsumedCapacity: t.totalCapacity(capacity),
+	}, deleted, nil
+}
+
+// deleteBatch removes the items using a batched delete then cleans up any offloaded payloads they reference
+func (t *Store[P, S, V]) deleteBatch(ctx context.Context, partitionKey P, items []map[string]types.AttributeValue) ([]types.ConsumedCapacity, error) {
+	requests := make([]types.WriteRequest, 0, len(items))
+
+	for _, item := range items {
+		requests = append(requests, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{
+				Key: map[string]types.AttributeValue{
+					t.fields.partitionKeyName: item[t.fields.partitionKeyName],
+					t.fields.sortKeyName:      item[t.fields.sortKeyName],
+				},
+			},
+		})
+	}
+
+	var sortKey S
+
+	// the batch spans many sort keys so the first is used to identify the batch to hooks
+	_ = attributevalue.Unmarshal(items[0][t.fields.sortKeyName], &sortKey)
+
+	used, err := t.batchWrite(ctx, partitionKey, sortKey, requests)
+
+	// the version isn't read by the query so the entries are removed rather than superseded
+	for _, item := range items {
+		var sk S
+		if attributevalue.Unmarshal(item[t.fields.sortKeyName], &sk) == nil {
+			t.invalidateCachedRecord(ctx, partitionKey, sk)
+		}
+	}
+
+	if err != nil {
+		return used, err
+	}
+
+	for _, item := range items {
+		err := t.deleteItemBlob(ctx, item)
+		if err != nil {
+			return used, err
+		}
+	}
+
+	return used, nil
+}
+
+// batchWrite dispatches the requests using BatchWriteItem in chunks of 25, retrying any unprocessed items with an
+// exponential backoff
+func (t *Store[P, S, V]) batchWrite(ctx context.Context, partitionKey P, sortKey S, requests []types.WriteRequest) ([]types.ConsumedCapacity, error) {
+	var used []types.ConsumedCapacity
+
+	for start := 0; start < len(requests); start += batchWriteMaxItems {
+		pending := requests[start:min(start+batchWriteMaxItems, len(requests))]
+		delay := batchWriteBaseDelay
+
+		for attempt := 1; len(pending) > 0; attempt++ {
+			if attempt > batchWriteMaxAttempts {
+				return used, fmt.Errorf("%w: %d items", ErrBatchWriteUnprocessed, len(pending))
+			}
+
+			if attempt > 1 {
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return used, ctx.Err()
+				}
+
+				delay = min(delay*2, batchWriteMaxDelay)
+			}
+
+			batchWriteItem := &dynamodb.BatchWriteItemInput{
+				RequestItems:           map[string][]types.WriteRequest{t.tableName: pending},
+				ReturnConsumedCapacity: t.storeOptions.consumedCapacity,
+			}
+
+			res, err := send(ctx, t, partitionKey, sortKey, batchWriteItem, t.client.BatchWriteItem)
+			if err != nil {
+				return used, fmt.Errorf("dynastorev2: failed to batch write records: %w", err)
+			}
+
+			used = append(used, res.ConsumedCapacity...)
+			pending = res.UnprocessedItems[t.tableName]
+		}
+	}
+
+	return used, nil
+}
@@ -0,0 +1,169 @@
+package dynastorev2
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// rateLimitBackoff is the fraction the rate is reduced to when a call is throttled
+	rateLimitBackoff = 0.5
+	// rateLimitMinFraction is the lowest fraction of the target rate the limiter will back off to
+	rateLimitMinFraction = 0.05
+	// rateLimitRecovery is the fraction of the target rate recovered each second after a call is throttled
+	rateLimitRecovery = 0.1
+)
+
+// RateLimiter limits the read and write capacity consumed by stores using a token bucket for each, the bucket is
+// charged with the capacity consumed by each call after it returns so calls wait while the bucket is in debt.
+//
+// The limiter adapts to throttling by halving the rate each time a call is throttled, then recovering towards the
+// target rate over the following seconds.
+//
+// A limiter should be shared by all the stores in a process which use the same table so they share the capacity.
+type RateLimiter struct {
+	read  *tokenBucket
+	write *tokenBucket
+}
+
+// NewRateLimiter creates a rate limiter with a target of the provided read and write capacity units per second, this is
+// typically a fraction of the provisioned capacity of the table. A target of zero disables limiting of reads or writes.
+func NewRateLimiter(readUnits, writeUnits float64) *RateLimiter {
+	return &RateLimiter{
+		read:  newTokenBucket(readUnits),
+		write: newTokenBucket(writeUnits),
+	}
+}
+
+// ReadRate returns the read capacity units per second currently permitted
+func (r *RateLimiter) ReadRate() float64 {
+	return r.read.currentRate()
+}
+
+// WriteRate returns the write capacity units per second currently permitted
+func (r *RateLimiter) WriteRate() float64 {
+	return r.write.currentRate()
+}
+
+// wait blocks until the bucket for the request has capacity available
+func (r *RateLimiter) wait(ctx context.Context, request *RequestEvent) error {
+	return r.bucket(request).wait(ctx)
+}
+
+// observe charges the bucket for the request with the capacity consumed, if the call was throttled the rate is reduced
+func (r *RateLimiter) observe(response *ResponseEvent) {
+	bucket := r.bucket(response.Request)
+
+	if response.Err != nil {
+		if IsThrottle(response.Err) {
+			bucket.throttled()
+		}
+
+		return
+	}
+
+	units := response.CapacityUnits()
+
+	// without consumed capacity the cost is estimated as one unit per item
+	if len(response.ConsumedCapacity) == 0 {
+		units = float64(max(response.ItemCount, 1))
+	}
+
+	bucket.consume(units)
+}
+
+func (r *RateLimiter) bucket(request *RequestEvent) *tokenBucket {
+	if request.Write {
+		return r.write
+	}
+
+	return r.read
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	target float64
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(target float64) *tokenBucket {
+	return &tokenBucket{
+		target: target,
+		rate:   target,
+		tokens: target,
+		last:   time.Now(),
+	}
+}
+
+func (b *tokenBucket) currentRate() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+
+	return b.rate
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b.target <= 0 {
+		return nil
+	}
+
+	for {
+		b.mu.Lock()
+		b.refill(time.Now())
+
+		if b.tokens > 0 {
+			b.mu.Unlock()
+			return nil
+		}
+
+		// wait until the debt is repaid and a unit is available at the current rate
+		delay := time.Duration((-b.tokens + 1) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (b *tokenBucket) consume(units float64) {
+	if b.target <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	b.tokens -= units
+}
+
+func (b *tokenBucket) throttled() {
+	if b.target <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	b.rate = max(b.rate*rateLimitBackoff, b.target*rateLimitMinFraction)
+	b.tokens = min(b.tokens, 0)
+}
+
+// refill adds the tokens accrued since the last refill and recovers the rate towards the target, the bucket holds at
+// most one second of capacity
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+
+	b.rate = min(b.rate+b.target*rateLimitRecovery*elapsed, b.target)
+	b.tokens = min(b.tokens+b.rate*elapsed, b.rate)
+}
@@ -0,0 +1,94 @@
+package dynastorev2
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Record is a decoded record returned by scan operations, containing the keys, version, expiry and extra fields
+// stored alongside the payload.
+type Record[P Key, S Key, V any] struct {
+	PartitionKey P              `json:"partition_key"`
+	SortKey      S              `json:"sort_key"`
+	Version      int64          `json:"version"`
+	Expires      *time.Time     `json:"expires,omitempty"`
+	Fields       map[string]any `json:"fields,omitempty"`
+	Value        V              `json:"value"`
+}
+
+// decodeRecord decodes the keys, version, expiry, extra fields and payload from the item, applying any schema upgrades
+// to the payload
+func (t *Store[P, S, V]) decodeRecord(ctx context.Context, item map[string]types.AttributeValue) (Record[P, S, V], bool, error) {
+	record, err := t.decodeRecordMetadata(item)
+	if err != nil {
+		return record, false, err
+	}
+
+	val, upgraded, err := t.decodePayload(ctx, item)
+	if err != nil {
+		return record, false, err
+	}
+
+	record.Value = val
+
+	return record, upgraded, nil
+}
+
+// decodeRecordMetadata decodes the keys, version, expiry and extra fields from the item without the payload
+func (t *Store[P, S, V]) decodeRecordMetadata(item map[string]types.AttributeValue) (Record[P, S, V], error) {
+	var record Record[P, S, V]
+
+	err := attributevalue.Unmarshal(item[t.fields.partitionKeyName], &record.PartitionKey)
+	if err != nil {
+		return record, fmt.Errorf("dynastorev2: failed to extract partition key attribute: %w", err)
+	}
+
+	err = attributevalue.Unmarshal(item[t.fields.sortKeyName], &record.SortKey)
+	if err != nil {
+		return record, fmt.Errorf("dynastorev2: failed to extract sort key attribute: %w", err)
+	}
+
+	if attr, ok := item[t.fields.versionName]; ok {
+		err := attributevalue.Unmarshal(attr, &record.Version)
+		if err != nil {
+			return record, fmt.Errorf("dynastorev2: failed to extract version attribute: %w", err)
+		}
+	}
+
+	if attr, ok := item[t.fields.expiresName]; ok {
+		var expires int64
+
+		err := attributevalue.Unmarshal(attr, &expires)
+		if err != nil {
+			return record, fmt.Errorf("dynastorev2: failed to extract expires attribute: %w", err)
+		}
+
+		ts := time.Unix(expires, 0).UTC()
+		record.Expires = &ts
+	}
+
+	for k, attr := range item {
+		if t.isReservedField(k) {
+			continue
+		}
+
+		var val any
+
+		err := attributevalue.Unmarshal(attr, &val)
+		if err != nil {
+			return record, fmt.Errorf("dynastorev2: failed to extract extra field: %w", err)
+		}
+
+		if record.Fields == nil {
+			record.Fields = make(map[string]any)
+		}
+
+		record.Fields[k] = val
+	}
+
+	return record, nil
+}
@@ -1,6 +1,9 @@
 package dynastorev2
 
-import "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
 
 // OperationResult returned with operations to provide some information about the update
 type OperationResult struct {
@@ -8,3 +11,38 @@ type OperationResult struct {
 	ConsumedCapacity *types.ConsumedCapacity `json:"consumed_capacity,omitempty"`
 	LastEvaluatedKey string                  `json:"last_evaluated_key,omitempty"`
 }
+
+// CapacityUnits returns the total capacity units consumed by the operation, zero if consumed capacity wasn't returned
+func (r *OperationResult) CapacityUnits() float64 {
+	if r == nil || r.ConsumedCapacity == nil {
+		return 0
+	}
+
+	return aws.ToFloat64(r.ConsumedCapacity.CapacityUnits)
+}
+
+// IndexCapacityUnits returns the capacity units consumed by each global and local secondary index, keyed by index
+// name. This is only available if the store was configured with WithReturnConsumedCapacity using INDEXES, otherwise
+// it returns nil.
+func (r *OperationResult) IndexCapacityUnits() map[string]float64 {
+	if r == nil || r.ConsumedCapacity == nil {
+		return nil
+	}
+
+	cc := r.ConsumedCapacity
+	if len(cc.GlobalSecondaryIndexes) == 0 && len(cc.LocalSecondaryIndexes) == 0 {
+		return nil
+	}
+
+	units := make(map[string]float64, len(cc.GlobalSecondaryIndexes)+len(cc.LocalSecondaryIndexes))
+
+	for index, c := range cc.GlobalSecondaryIndexes {
+		units[index] += aws.ToFloat64(c.CapacityUnits)
+	}
+
+	for index, c := range cc.LocalSecondaryIndexes {
+		units[index] += aws.ToFloat64(c.CapacityUnits)
+	}
+
+	return units
+}
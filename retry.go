@@ -0,0 +1,169 @@
+package dynastorev2
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+const (
+	// DefaultRetryMaxAttempts is the default number of attempts made for each call, including the first
+	DefaultRetryMaxAttempts = 5
+	// DefaultRetryBaseDelay is the default delay before the first retry, this doubles with each attempt
+	DefaultRetryBaseDelay = 50 * time.Millisecond
+	// DefaultRetryMaxDelay is the default upper bound of the delay between attempts
+	DefaultRetryMaxDelay = 5 * time.Second
+)
+
+// RetryPolicy configures how calls to DynamoDB which fail with throttling or transient errors are retried, the delay
+// between attempts grows exponentially from BaseDelay up to MaxDelay with full jitter.
+//
+// Conditional check failures are never retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts made for each call including the first, defaults to DefaultRetryMaxAttempts
+	MaxAttempts int
+	// MaxElapsed is the maximum time spent on a call including the delays between attempts, zero means no limit
+	MaxElapsed time.Duration
+	// BaseDelay is the delay before the first retry, defaults to DefaultRetryBaseDelay
+	BaseDelay time.Duration
+	// MaxDelay is the upper bound of the delay between attempts, defaults to DefaultRetryMaxDelay
+	MaxDelay time.Duration
+	// Retryable classifies errors which should be retried, defaults to IsRetryable
+	Retryable func(err error) bool
+}
+
+// RetryEvent describes a failed call to DynamoDB which is about to be retried
+type RetryEvent struct {
+	// Request which failed
+	Request *RequestEvent
+	// Attempt which failed, starting at 1
+	Attempt int
+	// Delay before the next attempt
+	Delay time.Duration
+	// Err returned by the failed attempt
+	Err error
+}
+
+// IsRetryable returns true if the error is caused by throttling, a transaction conflict or a transient service error.
+//
+// Conditional check failures are not retryable.
+func IsRetryable(err error) bool {
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return false
+	}
+
+	if IsThrottle(err) {
+		return true
+	}
+
+	var ae smithy.APIError
+	if !errors.As(err, &ae) {
+		return false
+	}
+
+	switch ae.ErrorCode() {
+	case "TransactionConflictException", "InternalServerError", "ServiceUnavailable":
+		return true
+	default:
+		return false
+	}
+}
+
+// IsThrottle returns true if the error is caused by exceeding the capacity of the table or the request rate of the account.
+func IsThrottle(err error) bool {
+	var ae smithy.APIError
+	if !errors.As(err, &ae) {
+		return false
+	}
+
+	switch ae.ErrorCode() {
+	case "ProvisionedThroughputExceededException", "RequestLimitExceeded", "ThrottlingException":
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return false
+	}
+
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+
+	return IsRetryable(err)
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts <= 0 {
+		return DefaultRetryMaxAttempts
+	}
+
+	return p.MaxAttempts
+}
+
+// delay returns the delay before the next attempt using exponential backoff with full jitter
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	base, maxDelay := p.BaseDelay, p.MaxDelay
+
+	if base <= 0 {
+		base = DefaultRetryBaseDelay
+	}
+
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryMaxDelay
+	}
+
+	// compare against the maximum shifted down so a large attempt count can't overflow the backoff
+	backoff := maxDelay
+	if shift := attempt - 1; base <= maxDelay>>shift {
+		backoff = base << shift
+	}
+
+	return rand.N(backoff) + 1
+}
+
+// sendWithRetry dispatches the request using the retry policy of the store, the store hooks are invoked for each attempt
+func sendWithRetry[P Key, S Key, V any, In any, Out any](ctx context.Context, t *Store[P, S, V], partitionKey P, sortKey S, params *In, call func(context.Context, *In, ...func(*dynamodb.Options)) (*Out, error)) (*Out, error) {
+	policy := t.storeOptions.retryPolicy
+	if policy == nil {
+		return sendWithHooks(ctx, t, partitionKey, sortKey, params, call, 1)
+	}
+
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		out, err := sendWithHooks(ctx, t, partitionKey, sortKey, params, call, attempt)
+		if err == nil || attempt >= policy.maxAttempts() || !policy.retryable(err) {
+			return out, err
+		}
+
+		delay := policy.delay(attempt)
+
+		if policy.MaxElapsed > 0 && time.Since(start)+delay > policy.MaxElapsed {
+			return out, err
+		}
+
+		if hooks := t.storeOptions.storeHooks; hooks != nil && hooks.OnRetry != nil {
+			event := newRequestEvent(ctx, t.tableName, params)
+			event.Attempt = attempt
+
+			hooks.OnRetry(ctx, &RetryEvent{Request: event, Attempt: attempt, Delay: delay, Err: err})
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
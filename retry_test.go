@@ -0,0 +1,41 @@
+package dynastorev2
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		attempt  int
+		maxDelay time.Duration
+	}{
+		{name: "first attempt", policy: RetryPolicy{}, attempt: 1, maxDelay: DefaultRetryBaseDelay},
+		{name: "doubles each attempt", policy: RetryPolicy{}, attempt: 4, maxDelay: 8 * DefaultRetryBaseDelay},
+		{name: "capped at max delay", policy: RetryPolicy{}, attempt: 20, maxDelay: DefaultRetryMaxDelay},
+		{name: "shift overflows", policy: RetryPolicy{BaseDelay: 5 * time.Second, MaxDelay: time.Minute}, attempt: 35, maxDelay: time.Minute},
+		{name: "shift exceeds width", policy: RetryPolicy{BaseDelay: 5 * time.Second, MaxDelay: time.Minute}, attempt: 100, maxDelay: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				if d := tt.policy.delay(tt.attempt); d <= 0 || d > tt.maxDelay {
+					t.Fatalf("expected delay in (0, %s] got %s", tt.maxDelay, d)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelayLargeAttempts(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 5 * time.Second, MaxDelay: 30 * time.Second}
+
+	for attempt := 1; attempt <= 1000; attempt++ {
+		if d := policy.delay(attempt); d <= 0 || d > policy.MaxDelay {
+			t.Fatalf("expected delay in (0, %s] for attempt %d got %s", policy.MaxDelay, attempt, d)
+		}
+	}
+}
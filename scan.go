@@ -0,0 +1,283 @@
+package dynastorev2
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	dexp "github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// scanSegmentDone marks a segment which has been read to the end in a scan cursor, this isn't a valid pagination token
+const scanSegmentDone = "."
+
+// scanCursor holds the pagination token of each segment of a scan so it can be resumed
+type scanCursor struct {
+	Segments []string `json:"segments"`
+}
+
+// Scan reads every record in the table, or the index provided using ReadWithIndex, invoking the callback with each
+// decoded record. The table is divided into segments using ReadWithSegments which are read in parallel by a pool of
+// workers, the size of the pool defaults to the number of segments and can be limited using ReadWithConcurrency.
+//
+// If the callback returns an error, or the context is cancelled, the scan stops and the returned result contains a
+// cursor in LastEvaluatedKey which can be passed to ReadWithLastEvaluatedKey to resume the scan. Resuming restarts
+// each segment from the start of the page which was being processed, so some records may be visited more than once.
+//
+// Notes:
+// 1. The callback is invoked concurrently from each worker so it must be safe for concurrent use.
+// 2. Records which don't match the filter provided using ReadWithFilter are excluded by DynamoDB, but still consume read capacity.
+// 3. Scan will also return expired records as these may hang around for up to 48 hours according to the documentation, see: https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/howitworks-ttl.html
+func (t *Store[P, S, V]) Scan(ctx context.Context, fn func(ctx context.Context, record Record[P, S, V]) error, options ...ReadOption[P, S]) (*OperationResult, error) {
+	ctx = setOperationDetails(ctx, "Scan", "", "")
+
+	return t.scan(ctx, func(ctx context.Context, _ map[string]types.AttributeValue, record Record[P, S, V]) error {
+		return fn(ctx, record)
+	}, options...)
+}
+
+// scan reads every record in the table as described by Scan, invoking the callback with each item along with the
+// record decoded from it
+func (t *Store[P, S, V]) scan(ctx context.Context, fn func(ctx context.Context, item map[string]types.AttributeValue, record Record[P, S, V]) error, options ...ReadOption[P, S]) (*OperationResult, error) {
+	defaultOpts := t.defaultReadOptions()
+	ApplyReadOptions(defaultOpts, options...)
+
+	segments := defaultOpts.segments
+	if segments < 1 {
+		segments = 1
+	}
+
+	cursor := make([]string, segments)
+
+	// when resuming the number of segments is provided by the cursor
+	if defaultOpts.lastEvaluatedKey != "" {
+		var err error
+
+		cursor, err = decodeScanCursor(defaultOpts.lastEvaluatedKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	concurrency := defaultOpts.concurrency
+	if concurrency < 1 || concurrency > len(cursor) {
+		concurrency = len(cursor)
+	}
+
+	var expr dexp.Expression
+
+	if filter := t.readFilter(defaultOpts); filter.IsSet() {
+		var err error
+
+		expr, err = dexp.NewBuilder().WithFilter(filter).Build()
+		if err != nil {
+			return nil, fmt.Errorf("dynastorev2: failed to build scan expression: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		capacity = &types.ConsumedCapacity{TableName: aws.String(t.tableName), CapacityUnits: aws.Float64(0)}
+	)
+
+	pending := make(chan int, len(cursor))
+
+	for segment, token := range cursor {
+		if token != scanSegmentDone {
+			pending <- segment
+		}
+	}
+
+	close(pending)
+
+	for range concurrency {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for segment := range pending {
+				if ctx.Err() != nil {
+					return
+				}
+
+				err := t.scanSegment(ctx, segment, expr, defaultOpts, &mu, cursor, capacity, fn)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+					mu.Unlock()
+
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	result := &OperationResult{
+		ConsumedCapacity: t.totalCapacity(capacity),
+	}
+
+	if firstErr == nil && ctx.Err() != nil {
+		firstErr = ctx.Err()
+	}
+
+	if firstErr != nil {
+		lastEvaluatedKey, err := encodeScanCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		result.LastEvaluatedKey = lastEvaluatedKey
+
+		return result, firstErr
+	}
+
+	return result, nil
+}
+
+// ReadWithSegments sets the number of segments the table is divided into when performing scan operations
+func (t *Store[P, S, V]) ReadWithSegments(segments int) ReadOption[P, S] {
+	return readWithSegments[P, S](segments)
+}
+
+// ReadWithConcurrency sets the maximum number of segments read concurrently when performing scan operations
+func (t *Store[P, S, V]) ReadWithConcurrency(concurrency int) ReadOption[P, S] {
+	return readWithConcurrency[P, S](concurrency)
+}
+
+// scanSegment reads the segment page by page from the position in the cursor, updating the cursor as each page is
+// completed
+func (t *Store[P, S, V]) scanSegment(ctx context.Context, segment int, expr dexp.Expression, options *ReadOptions[P, S], mu *sync.Mutex, cursor []string, capacity *types.ConsumedCapacity, fn func(ctx context.Context, item map[string]types.AttributeValue, record Record[P, S, V]) error) error {
+	scanInput := &dynamodb.ScanInput{
+		TableName:                 aws.String(t.tableName),
+		ReturnConsumedCapacity:    t.storeOptions.consumedCapacity,
+		ConsistentRead:            aws.Bool(options.consistentRead),
+		Segment:                   aws.Int32(int32(segment)),
+		TotalSegments:             aws.Int32(int32(len(cursor))),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	if options.indexName != "" {
+		scanInput.IndexName = aws.String(options.indexName)
+	}
+
+	if options.limit > 0 {
+		scanInput.Limit = aws.Int32(options.limit)
+	}
+
+	mu.Lock()
+	token := cursor[segment]
+	mu.Unlock()
+
+	if token != "" {
+		startKey, err := decodeStartKey(token)
+		if err != nil {
+			return err
+		}
+
+		scanInput.ExclusiveStartKey = startKey
+	}
+
+	for {
+		var (
+			partitionKey P
+			sortKey      S
+		)
+
+		res, err := send(ctx, t, partitionKey, sortKey, scanInput, t.client.Scan)
+		if err != nil {
+			return fmt.Errorf("dynastorev2: failed to execute scan: %w", err)
+		}
+
+		if res.ConsumedCapacity != nil {
+			mu.Lock()
+			addCapacity(capacity, *res.ConsumedCapacity)
+			mu.Unlock()
+		}
+
+		for _, item := range res.Items {
+			record, upgraded, err := t.decodeRecord(ctx, item)
+			if err != nil {
+				return err
+			}
+
+			if upgraded && t.storeOptions.schemaWriteBack {
+				// the write back is best effort, if it fails the upgrade is applied again on the next read
+				if newVersion, err := t.writeBackUpgrade(ctx, item, record.Value, record.Version); err == nil {
+					record.Version = newVersion
+				}
+			}
+
+			err = fn(ctx, item, record)
+			if err != nil {
+				return err
+			}
+		}
+
+		token, err := encodeStartKey(res.LastEvaluatedKey)
+		if err != nil {
+			return err
+		}
+
+		if token == "" {
+			token = scanSegmentDone
+		}
+
+		mu.Lock()
+		cursor[segment] = token
+		mu.Unlock()
+
+		if len(res.LastEvaluatedKey) == 0 {
+			return nil
+		}
+
+		scanInput.ExclusiveStartKey = res.LastEvaluatedKey
+	}
+}
+
+func decodeScanCursor(lastEvaluatedKey string) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(lastEvaluatedKey)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to decode scan cursor: %w", err)
+	}
+
+	var sc scanCursor
+
+	err = json.Unmarshal(data, &sc)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to unmarshal scan cursor: %w", err)
+	}
+
+	if len(sc.Segments) == 0 {
+		return nil, errors.New("dynastorev2: scan cursor contains no segments")
+	}
+
+	return sc.Segments, nil
+}
+
+func encodeScanCursor(segments []string) (string, error) {
+	data, err := json.Marshal(&scanCursor{Segments: segments})
+	if err != nil {
+		return "", fmt.Errorf("dynastorev2: failed to marshal scan cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,90 @@
+package dynastorev2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	dexp "github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// SchemaUpgradeFunc upgrades a payload from the version it is registered under to the next version, the payload
+// is provided in its marshalled form so fields can be added, renamed or removed before it is unmarshalled into the value.
+type SchemaUpgradeFunc func(ctx context.Context, payload types.AttributeValue) (types.AttributeValue, error)
+
+// upgradePayload runs the registered upgrade functions over the payload until it reaches the current schema version,
+// returning true if the payload was upgraded. Records without a schema version attribute are treated as version zero.
+func (t *Store[P, S, V]) upgradePayload(ctx context.Context, item map[string]types.AttributeValue, payload types.AttributeValue) (types.AttributeValue, bool, error) {
+	if t.storeOptions.schemaVersion == 0 {
+		return payload, false, nil
+	}
+
+	var schemaVersion int64
+	if attr, ok := item[t.fields.schemaVersionName]; ok {
+		err := attributevalue.Unmarshal(attr, &schemaVersion)
+		if err != nil {
+			return nil, false, fmt.Errorf("dynastorev2: failed to extract schema version attribute: %w", err)
+		}
+	}
+
+	upgraded := false
+
+	for ; schemaVersion < t.storeOptions.schemaVersion; schemaVersion++ {
+		upgrade, ok := t.storeOptions.schemaUpgrades[schemaVersion]
+		if !ok {
+			return nil, false, fmt.Errorf("%w: version %d", ErrSchemaUpgradeMissing, schemaVersion)
+		}
+
+		var err error
+
+		payload, err = upgrade(ctx, payload)
+		if err != nil {
+			return nil, false, fmt.Errorf("dynastorev2: failed to upgrade payload from schema version %d: %w", schemaVersion, err)
+		}
+
+		upgraded = true
+	}
+
+	return payload, upgraded, nil
+}
+
+// writeBackUpgrade persists an upgraded value using an update conditioned on the version which was read, returning
+// the new version. If the record was modified since it was read the upgraded value is discarded and the version read
+// is returned.
+func (t *Store[P, S, V]) writeBackUpgrade(ctx context.Context, item map[string]types.AttributeValue, value V, version int64) (int64, error) {
+	var (
+		partitionKey P
+		sortKey      S
+	)
+
+	err := attributevalue.Unmarshal(item[t.fields.partitionKeyName], &partitionKey)
+	if err != nil {
+		return version, fmt.Errorf("dynastorev2: failed to extract partition key: %w", err)
+	}
+
+	err = attributevalue.Unmarshal(item[t.fields.sortKeyName], &sortKey)
+	if err != nil {
+		return version, fmt.Errorf("dynastorev2: failed to extract sort key: %w", err)
+	}
+
+	condition := t.WriteWithVersion(version)
+
+	// items written without a version, for example by incrementing a counter, can only be conditioned on it being absent
+	if version == 0 {
+		condition = t.WriteWithCondition(dexp.AttributeNotExists(dexp.Name(t.fields.versionName)))
+	}
+
+	res, err := t.Update(ctx, partitionKey, sortKey, value, condition)
+	if err != nil {
+		var oe *types.ConditionalCheckFailedException
+		if errors.As(err, &oe) {
+			return version, nil
+		}
+
+		return version, fmt.Errorf("dynastorev2: failed to write back upgraded payload: %w", err)
+	}
+
+	return res.Version, nil
+}
@@ -0,0 +1,213 @@
+package dynastorev2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	dexp "github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Restore a soft deleted record in DynamoDB using the provided partition and sort keys, this removes the tombstone
+// and puts back the TTL the record had before it was deleted, unless a new TTL is provided using WriteWithTTL.
+//
+// Notes:
+// 1. Records can only be restored before the retention period provided to WithSoftDelete has elapsed.
+// 2. If the record doesn't exist or isn't deleted ErrRestoreFailedKeyNotDeleted is returned.
+func (t *Store[P, S, V]) Restore(ctx context.Context, partitionKey P, sortKey S, options ...WriteOption[P, S, V]) (*OperationResult, error) {
+	ctx = setOperationDetails(ctx, "Restore", partitionKey, sortKey)
+
+	defaultOpts := t.defaultWriteOptions()
+	ApplyWriteOptions(defaultOpts, options...)
+
+	if err := t.checkCondition(defaultOpts.condition); err != nil {
+		return nil, err
+	}
+
+	if defaultOpts.ttl > 0 {
+		return t.restore(ctx, partitionKey, sortKey, defaultOpts, dexp.ConditionBuilder{}, false)
+	}
+
+	// the tombstone holds the TTL the record had before it was deleted, or zero if it didn't expire. Each attempt is
+	// conditional on the saved TTL so only one can succeed, records which didn't expire are tried first as they are
+	// the most common.
+	deletedExpires := dexp.Name(t.fields.deletedExpiresName)
+
+	defaultOpts.ttlCleared = true
+
+	res, err := t.restore(ctx, partitionKey, sortKey, defaultOpts,
+		dexp.AttributeNotExists(deletedExpires).Or(dexp.Equal(deletedExpires, dexp.Value(0))), false)
+	if !errors.Is(err, ErrRestoreFailedKeyNotDeleted) {
+		return res, err
+	}
+
+	defaultOpts.ttlCleared = false
+
+	return t.restore(ctx, partitionKey, sortKey, defaultOpts, dexp.GreaterThan(deletedExpires, dexp.Value(0)), true)
+}
+
+// restore removes the tombstone from the record if it is deleted, hasn't expired and matches the provided condition,
+// if restoreExpires is true the TTL saved in the tombstone is put back
+func (t *Store[P, S, V]) restore(ctx context.Context, partitionKey P, sortKey S, options *WriteOptions[P, S, V], condition dexp.ConditionBuilder, restoreExpires bool) (*OperationResult, error) {
+	// increment the version attribute by one and remove the tombstone
+	update := dexp.Add(dexp.Name(t.fields.versionName), dexp.Value(1)).
+		Remove(dexp.Name(t.fields.deletedAtName)).
+		Remove(dexp.Name(t.fields.deletedExpiresName))
+
+	if restoreExpires {
+		update = update.Set(dexp.Name(t.fields.expiresName), dexp.Name(t.fields.deletedExpiresName))
+	}
+
+	update, err := t.addWriteFields(update, options)
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to build update: %w", err)
+	}
+
+	// the record must be deleted and not yet expired, as expired records may hang around until they are cleaned up
+	restoreCondition := dexp.AttributeExists(dexp.Name(t.fields.deletedAtName)).
+		And(dexp.GreaterThan(dexp.Name(t.fields.expiresName), dexp.Value(time.Now().Unix())))
+
+	if options.version > 0 {
+		restoreCondition = restoreCondition.And(dexp.Equal(dexp.Name(t.fields.versionName), dexp.Value(options.version)))
+	}
+
+	restoreCondition = andConditions(restoreCondition, condition)
+	restoreCondition = andConditions(restoreCondition, options.condition)
+
+	expr, err := dexp.NewBuilder().WithUpdate(update).WithCondition(restoreCondition).Build()
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to build update expression: %w", err)
+	}
+
+	result, err := t.doUpdate(ctx, partitionKey, sortKey, expr, types.ReturnValueAllNew, nil)
+	if err != nil {
+		var oe *types.ConditionalCheckFailedException
+		if errors.As(err, &oe) {
+			return nil, ErrRestoreFailedKeyNotDeleted
+		}
+
+		return nil, err
+	}
+
+	var version int64
+	if attr, ok := result.Attributes[t.fields.versionName]; ok {
+		err := attributevalue.Unmarshal(attr, &version)
+		if err != nil {
+			return nil, fmt.Errorf("dynastorev2: failed to extract version attribute: %w", err)
+		}
+	}
+
+	return &OperationResult{
+		Version:          version,
+		ConsumedCapacity: result.ConsumedCapacity,
+	}, nil
+}
+
+// softDelete marks the record as deleted with a tombstone and assigns a TTL of the retention period, the payload,
+// any offloaded blob and the TTL of the record are retained so the record can be restored
+func (t *Store[P, S, V]) softDelete(ctx context.Context, partitionKey P, sortKey S, options *DeleteOptions[P, S], returnValues types.ReturnValue) (*dynamodb.UpdateItemOutput, error) {
+	now := time.Now()
+
+	// increment the version attribute by one so readers holding the previous version can't update the record, the
+	// TTL of the record is saved in the tombstone, or zero if it doesn't expire
+	update := dexp.Add(dexp.Name(t.fields.versionName), dexp.Value(1)).
+		Set(dexp.Name(t.fields.deletedAtName), dexp.Value(now.Unix())).
+		Set(dexp.Name(t.fields.deletedExpiresName), dexp.IfNotExists(dexp.Name(t.fields.expiresName), dexp.Value(0))).
+		Set(dexp.Name(t.fields.expiresName), dexp.Value(now.Add(t.storeOptions.softDeleteRetention).Unix()))
+
+	// the record must always exist, otherwise the update would create a tombstone for a record which never existed
+	deleteCondition := t.existsCondition()
+
+	if options.version > 0 {
+		deleteCondition = deleteCondition.And(dexp.Equal(dexp.Name(t.fields.versionName), dexp.Value(options.version)))
+	}
+
+	deleteCondition = andConditions(deleteCondition, options.condition)
+
+	expr, err := dexp.NewBuilder().WithUpdate(update).WithCondition(deleteCondition).Build()
+	if err != nil {
+		return nil, fmt.Errorf("dynastorev2: failed to build update expression: %w", err)
+	}
+
+	key, err := t.buildKey(partitionKey, sortKey)
+	if err != nil {
+		return nil, err
+	}
+
+	updateItem := &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(t.tableName),
+		Key:                       key,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ReturnConsumedCapacity:    t.storeOptions.consumedCapacity,
+		ReturnValues:              returnValues,
+		// the existing item is returned when the condition fails to determine whether the record was missing, already
+		// deleted or the provided version or condition wasn't met
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}
+
+	updateResp, err := send(ctx, t, partitionKey, sortKey, updateItem, t.client.UpdateItem)
+	if err != nil {
+		t.invalidateCachedRecord(ctx, partitionKey, sortKey)
+
+		var oe *types.ConditionalCheckFailedException
+		if errors.As(err, &oe) {
+			if len(oe.Item) > 0 && !t.isDeleted(oe.Item) {
+				return nil, ErrDeleteFailedConditionCheck
+			}
+
+			// as with a delete, a missing record is only an error if the exists check is enabled
+			if !options.existsCheck {
+				return &dynamodb.UpdateItemOutput{}, nil
+			}
+
+			return nil, ErrDeleteFailedKeyNotExists
+		}
+
+		return nil, fmt.Errorf("dynastorev2: failed to delete record: %w", err)
+	}
+
+	// the tombstone increments the version of the old item
+	if returnValues == types.ReturnValueAllOld {
+		t.supersedeCachedRecord(ctx, partitionKey, sortKey, t.itemVersion(updateResp.Attributes)+1)
+	} else {
+		t.invalidateCachedRecord(ctx, partitionKey, sortKey)
+	}
+
+	return updateResp, nil
+}
+
+// existsCondition requires the record to exist, and when soft delete is enabled to not be deleted
+func (t *Store[P, S, V]) existsCondition() dexp.ConditionBuilder {
+	cond := dexp.AttributeExists(dexp.Name(t.fields.partitionKeyName)).And(dexp.AttributeExists(dexp.Name(t.fields.sortKeyName)))
+
+	if t.storeOptions.softDeleteRetention > 0 {
+		cond = cond.And(dexp.AttributeNotExists(dexp.Name(t.fields.deletedAtName)))
+	}
+
+	return cond
+}
+
+// notExistsCondition requires the record to not exist, and when soft delete is enabled allows it to be deleted
+func (t *Store[P, S, V]) notExistsCondition() dexp.ConditionBuilder {
+	cond := dexp.AttributeNotExists(dexp.Name(t.fields.partitionKeyName)).And(dexp.AttributeNotExists(dexp.Name(t.fields.sortKeyName)))
+
+	if t.storeOptions.softDeleteRetention > 0 {
+		cond = cond.Or(dexp.AttributeExists(dexp.Name(t.fields.deletedAtName)))
+	}
+
+	return cond
+}
+
+// isDeleted returns true if the item has been soft deleted
+func (t *Store[P, S, V]) isDeleted(item map[string]types.AttributeValue) bool {
+	_, ok := item[t.fields.deletedAtName]
+	return ok
+}
@@ -0,0 +1,48 @@
+package dynastorev2
+
+import "fmt"
+
+// Validator can be implemented by values stored to validate them before they are written
+type Validator interface {
+	Validate() error
+}
+
+// ValidationError is returned when a value fails validation prior to being written
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("dynastorev2: validation failed: %v", e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// validate runs the validator configured for the store, followed by the Validate method if the value implements Validator
+func (t *Store[P, S, V]) validate(value V) error {
+	if t.storeOptions.validator != nil {
+		if err := t.storeOptions.validator(value); err != nil {
+			return &ValidationError{Err: err}
+		}
+	}
+
+	var validator Validator
+
+	switch v := any(value).(type) {
+	case Validator:
+		validator = v
+	default:
+		// support values which implement Validate using a pointer receiver
+		validator, _ = any(&value).(Validator)
+	}
+
+	if validator != nil {
+		if err := validator.Validate(); err != nil {
+			return &ValidationError{Err: err}
+		}
+	}
+
+	return nil
+}